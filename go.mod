@@ -3,28 +3,34 @@ module github.com/decentralgabe/ceramic-client-golang
 go 1.17
 
 require (
+	github.com/btcsuite/btcd/btcec/v2 v2.1.3
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/ipfs/go-cid v0.0.7
 	github.com/magefile/mage v1.11.0
 	github.com/multiformats/go-multibase v0.0.3
 	github.com/multiformats/go-multicodec v0.2.0
+	github.com/multiformats/go-multihash v0.0.13
 	github.com/multiformats/go-varint v0.0.6
 	github.com/ockam-network/did v0.1.4-0.20210103172416-02ae01ce06d8
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
 	github.com/stretchr/testify v1.7.0
 	github.com/textileio/go-did-resolver v0.0.0-20210324200716-f291c2276a1d
+	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
 	github.com/jorrizza/ed2curve25519 v0.1.0 // indirect
 	github.com/minio/blake2b-simd v0.0.0-20160723061019-3f5f724cb5b1 // indirect
 	github.com/minio/sha256-simd v0.1.1-0.20190913151208-6de447530771 // indirect
 	github.com/mr-tron/base58 v1.1.3 // indirect
 	github.com/multiformats/go-base32 v0.0.3 // indirect
 	github.com/multiformats/go-base36 v0.1.0 // indirect
-	github.com/multiformats/go-multihash v0.0.13 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spaolacci/murmur3 v1.1.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	golang.org/x/sys v0.0.0-20210223095934-7937bea0104d // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -0,0 +1,189 @@
+package dagcbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"reflect"
+	"strconv"
+)
+
+// https://ipld.io/specs/codecs/dag-cbor/spec/
+
+const (
+	cidTag = 42
+
+	// linkKey is the dag-json representation of a link: {"/": "<cid>"}
+	linkKey = "/"
+)
+
+var (
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+)
+
+func init() {
+	encOpts := cbor.CanonicalEncOptions()
+	encOpts.ShortestFloat = cbor.ShortestFloatNone
+	em, err := encOpts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	encMode = em
+
+	dm, err := cbor.DecOptions{
+		IntDec:         cbor.IntDecConvertSigned,
+		DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	decMode = dm
+}
+
+// Encode serializes v as dag-cbor. v is first converted to its JSON form; values of the form {"/": "<cid>"},
+// including cid.Cid values, are encoded as links.
+func Encode(v interface{}) ([]byte, error) {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	prepared, err := prepare(generic)
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(prepared)
+}
+
+// Decode deserializes a dag-cbor block. Links are returned as cid.Cid values, maps as map[string]interface{}.
+func Decode(block []byte) (interface{}, error) {
+	var v interface{}
+	if err := decMode.Unmarshal(block, &v); err != nil {
+		return nil, err
+	}
+	return resolveLinks(v)
+}
+
+// DecodeJSON deserializes a dag-cbor block into JSON, with links rendered as plain CID strings and byte strings as
+// base64.
+func DecodeJSON(block []byte) (json.RawMessage, error) {
+	v, err := Decode(block)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(linksToStrings(v))
+}
+
+// CID computes the CIDv1 of a dag-cbor block using sha2-256.
+func CID(block []byte) (cid.Cid, error) {
+	prefix := cid.Prefix{
+		Version:  1,
+		Codec:    cid.DagCBOR,
+		MhType:   multihash.SHA2_256,
+		MhLength: -1,
+	}
+	return prefix.Sum(block)
+}
+
+func prepare(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if link, ok := asLink(value); ok {
+			c, err := cid.Decode(link)
+			if err != nil {
+				return nil, fmt.Errorf("invalid link<%s>: %w", link, err)
+			}
+			return cbor.Tag{Number: cidTag, Content: append([]byte{0}, c.Bytes()...)}, nil
+		}
+		prepared := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			p, err := prepare(item)
+			if err != nil {
+				return nil, err
+			}
+			prepared[k] = p
+		}
+		return prepared, nil
+	case []interface{}:
+		prepared := make([]interface{}, len(value))
+		for i, item := range value {
+			p, err := prepare(item)
+			if err != nil {
+				return nil, err
+			}
+			prepared[i] = p
+		}
+		return prepared, nil
+	case json.Number:
+		if i, err := strconv.ParseInt(value.String(), 10, 64); err == nil {
+			return i, nil
+		}
+		return value.Float64()
+	}
+	return v, nil
+}
+
+func asLink(m map[string]interface{}) (string, bool) {
+	if len(m) != 1 {
+		return "", false
+	}
+	link, ok := m[linkKey].(string)
+	return link, ok
+}
+
+func resolveLinks(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case cbor.Tag:
+		if value.Number != cidTag {
+			return nil, fmt.Errorf("unsupported tag: %d", value.Number)
+		}
+		content, ok := value.Content.([]byte)
+		if !ok || len(content) == 0 || content[0] != 0 {
+			return nil, errors.New("malformed link")
+		}
+		return cid.Cast(content[1:])
+	case map[string]interface{}:
+		for k, item := range value {
+			resolved, err := resolveLinks(item)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = resolved
+		}
+	case []interface{}:
+		for i, item := range value {
+			resolved, err := resolveLinks(item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = resolved
+		}
+	}
+	return v, nil
+}
+
+func linksToStrings(v interface{}) interface{} {
+	switch value := v.(type) {
+	case cid.Cid:
+		return value.String()
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = linksToStrings(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = linksToStrings(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package dagcbor
+
+import (
+	"encoding/hex"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	t.Run("known CIDs", func(tt *testing.T) {
+		empty, err := Encode(map[string]interface{}{})
+		assert.NoError(tt, err)
+		assert.Equal(tt, []byte{0xa0}, empty)
+		emptyCID, err := CID(empty)
+		assert.NoError(tt, err)
+		assert.Equal(tt, "bafyreigbtj4x7ip5legnfznufuopl4sg4knzc2cof6duas4b3q2fy6swua", emptyCID.String())
+
+		null, err := Encode(nil)
+		assert.NoError(tt, err)
+		assert.Equal(tt, []byte{0xf6}, null)
+		nullCID, err := CID(null)
+		assert.NoError(tt, err)
+		assert.Equal(tt, "bafyreifqwkmiw256ojf2zws6tzjeonw6bpd5vza4i22ccpcq4hjv2ts7cm", nullCID.String())
+	})
+
+	t.Run("map keys are sorted by length first", func(tt *testing.T) {
+		block, err := Encode(map[string]int{"b": 1, "aa": 3, "a": 2})
+		assert.NoError(tt, err)
+		assert.Equal(tt, "a3616102616201626161"+"03", hex.EncodeToString(block))
+	})
+
+	t.Run("links", func(tt *testing.T) {
+		link, err := cid.Decode("bafyreigbtj4x7ip5legnfznufuopl4sg4knzc2cof6duas4b3q2fy6swua")
+		assert.NoError(tt, err)
+		block, err := Encode(map[string]interface{}{"link": map[string]string{"/": link.String()}})
+		assert.NoError(tt, err)
+		// tag 42 over the CID bytes with a leading multibase identity byte
+		assert.Equal(tt, "a1646c696e6bd82a582500"+hex.EncodeToString(link.Bytes()), hex.EncodeToString(block))
+
+		_, err = Encode(map[string]interface{}{"link": map[string]string{"/": "not a cid"}})
+		assert.Error(tt, err)
+	})
+
+	t.Run("floats keep their full width", func(tt *testing.T) {
+		block, err := Encode(1.5)
+		assert.NoError(tt, err)
+		assert.Equal(tt, "fb3ff8000000000000", hex.EncodeToString(block))
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	link, err := cid.Decode("bafyreigbtj4x7ip5legnfznufuopl4sg4knzc2cof6duas4b3q2fy6swua")
+	assert.NoError(t, err)
+	block, err := Encode(map[string]interface{}{
+		"data":  map[string]interface{}{"title": "first", "count": 2, "ratio": 1.5, "tags": []string{"a", "b"}},
+		"id":    map[string]string{"/": link.String()},
+		"empty": nil,
+	})
+	assert.NoError(t, err)
+
+	decoded, err := Decode(block)
+	assert.NoError(t, err)
+	fields := decoded.(map[string]interface{})
+	assert.Equal(t, link, fields["id"])
+	assert.Nil(t, fields["empty"])
+	assert.Equal(t, int64(2), fields["data"].(map[string]interface{})["count"])
+
+	decodedJSON, err := DecodeJSON(block)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"data":{"title":"first","count":2,"ratio":1.5,"tags":["a","b"]},"id":"`+link.String()+`","empty":null}`, string(decodedJSON))
+
+	reencoded, err := Encode(fields)
+	assert.NoError(t, err)
+	assert.Equal(t, block, reencoded)
+
+	_, err = Decode([]byte{0xd8, 0x2b, 0x40})
+	assert.Error(t, err)
+}
@@ -0,0 +1,365 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryCeramic is an in-memory Ceramic node for tests. It keeps the commits of every stream and derives state by
+// replaying them with the streams reducer. Signatures are not checked and commit CIDs are the dag-cbor CIDs of the
+// commits as submitted.
+type MemoryCeramic struct {
+	mu      sync.RWMutex
+	streams map[string]*memoryStream
+	pins    map[string]bool
+}
+
+type memoryStream struct {
+	id      streams.StreamID
+	records []streams.CommitRecord
+	commits []streams.CommitData
+	// anchorStatus overrides the reduced status of the tip while an anchor is requested but not done
+	anchorStatus streams.AnchorStatus
+}
+
+func NewMemoryCeramic() *MemoryCeramic {
+	return &MemoryCeramic{
+		streams: make(map[string]*memoryStream),
+		pins:    make(map[string]bool),
+	}
+}
+
+func (m *MemoryCeramic) GetStreamState(req api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, err := m.loadState(req.StreamID, req.Opts)
+	if err != nil {
+		return nil, err
+	}
+	return &api.StreamStateResponse{Response: *state, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) CreateStream(req api.CreateStreamRequest) (*api.CreateStreamResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, err := newCommitRecord(req.Genesis)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := streams.DecodeCommit(*record)
+	if err != nil {
+		return nil, err
+	}
+	if commit.Type != streams.GenesisCommitType {
+		return nil, fmt.Errorf("commit<%s> is not a genesis commit", record.CID)
+	}
+	state, err := streams.ApplyGenesis(streams.StreamType(req.Type), *commit)
+	if err != nil {
+		return nil, err
+	}
+
+	genesisCID, err := cid.Decode(record.CID)
+	if err != nil {
+		return nil, err
+	}
+	id := streams.NewStreamID(streams.StreamType(req.Type), genesisCID)
+	stream, ok := m.streams[id.String()]
+	if !ok {
+		stream = &memoryStream{
+			id:      id,
+			records: []streams.CommitRecord{*record},
+			commits: []streams.CommitData{*commit},
+		}
+		m.streams[id.String()] = stream
+	} else if state, err = streams.ReduceCommits(stream.id.Type, stream.commits); err != nil {
+		return nil, err
+	}
+	if req.Opts.PinningOpts != nil && req.Opts.Pin {
+		m.pins[id.String()] = true
+	}
+
+	return &api.CreateStreamResponse{
+		Response:     streams.StreamStateHolder{ID: id.String(), State: *state},
+		ResponseCode: http.StatusOK,
+	}, nil
+}
+
+// RequestAnchor marks the stream's tip as pending an anchor. Use Anchor or FailAnchor to complete the request.
+func (m *MemoryCeramic) RequestAnchor(req api.RequestAnchorRequest) (*api.RequestAnchorResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream, err := m.getStream(req.StreamID)
+	if err != nil {
+		return nil, err
+	}
+	if stream.commits[len(stream.commits)-1].Type != streams.AnchorCommitType {
+		stream.anchorStatus = streams.Pending
+	}
+	state, err := m.loadState(req.StreamID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &api.RequestAnchorResponse{
+		StreamID:     stream.id.String(),
+		AnchorStatus: state.AnchorStatus,
+		ResponseCode: http.StatusOK,
+	}, nil
+}
+
+// Anchor appends an anchor commit with the given proof to the stream, as an anchor service would.
+func (m *MemoryCeramic) Anchor(streamID string, proof streams.AnchorProof) (*streams.StreamState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream, err := m.getStream(streamID)
+	if err != nil {
+		return nil, err
+	}
+	proofRecord, err := newCommitRecord(proof)
+	if err != nil {
+		return nil, err
+	}
+	record, err := newCommitRecord(streams.AnchorCommit{
+		ID:    stream.id.Genesis.String(),
+		Prev:  stream.records[len(stream.records)-1].CID,
+		Proof: proofRecord.CID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	commit, err := streams.DecodeCommit(*record)
+	if err != nil {
+		return nil, err
+	}
+	commit.Proof = proof
+	return m.appendCommit(stream, *record, *commit)
+}
+
+// FailAnchor fails the pending anchor request of the stream.
+func (m *MemoryCeramic) FailAnchor(streamID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream, err := m.getStream(streamID)
+	if err != nil {
+		return err
+	}
+	if stream.anchorStatus != streams.Pending {
+		return fmt.Errorf("stream<%s> has no pending anchor", streamID)
+	}
+	stream.anchorStatus = streams.Failed
+	return nil
+}
+
+func (m *MemoryCeramic) QueryStream(req api.QueryStreamRequest) (*api.QueryStreamResponse, error) {
+	resp, err := m.QueryStreams(api.QueryStreamsRequest{Queries: []api.QueryStreamRequest{req}})
+	if err != nil {
+		return nil, err
+	}
+	return &api.QueryStreamResponse{Response: resp.Responses[req.StreamID], ResponseCode: resp.ResponseCode}, nil
+}
+
+func (m *MemoryCeramic) QueryStreams(req api.QueryStreamsRequest) (*api.QueryStreamsResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	responses := make(map[string]streams.StreamState)
+	for _, query := range req.Queries {
+		state, err := m.loadState(query.StreamID, nil)
+		if err != nil {
+			continue
+		}
+		responses[query.StreamID] = *state
+	}
+	return &api.QueryStreamsResponse{Responses: responses, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) GetCommits(req api.GetCommitsRequest) (*api.GetCommitsResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stream, err := m.getStream(req.StreamID)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]streams.CommitRecord, len(stream.records))
+	copy(records, stream.records)
+	return &api.GetCommitsResponse{
+		StreamID:     stream.id.String(),
+		Commits:      records,
+		ResponseCode: http.StatusOK,
+	}, nil
+}
+
+func (m *MemoryCeramic) ApplyCommit(req api.ApplyCommitRequest) (*api.ApplyCommitResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stream, err := m.getStream(req.StreamID)
+	if err != nil {
+		return nil, err
+	}
+	record, err := newCommitRecord(req.Commit)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := streams.DecodeCommit(*record)
+	if err != nil {
+		return nil, err
+	}
+	state, err := m.appendCommit(stream, *record, *commit)
+	if err != nil {
+		return nil, err
+	}
+	return &api.ApplyCommitResponse{Response: *state, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) AddToPinset(req api.AddToPinsetRequest) (*api.AddToPinsetResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.getStream(req.StreamID); err != nil {
+		return nil, err
+	}
+	m.pins[req.StreamID] = true
+	return &api.AddToPinsetResponse{StreamID: req.StreamID, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) RemoveFromPinset(req api.RemoveFromPinsetRequest) (*api.RemoveFromPinsetResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pins, req.StreamID)
+	return &api.RemoveFromPinsetResponse{StreamID: req.StreamID, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) ListStreamsInPinset() (*api.ListStreamsInPinsetResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pinned := make([]string, 0, len(m.pins))
+	for id := range m.pins {
+		pinned = append(pinned, id)
+	}
+	sort.Strings(pinned)
+	return &api.ListStreamsInPinsetResponse{PinnedStreamIDs: pinned, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) ConfirmStreamInPinset(req api.ConfirmStreamInPinsetRequest) (*api.ConfirmStreamInPinsetResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var pinned []string
+	if m.pins[req.StreamID] {
+		pinned = []string{req.StreamID}
+	}
+	return &api.ConfirmStreamInPinsetResponse{PinnedStreamIDs: pinned, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) GetSupportedBlockchains() (*api.GetSupportedBlockchainsResponse, error) {
+	return &api.GetSupportedBlockchainsResponse{SupportedChains: []string{"inmemory:12345"}, ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) HealthCheck() (*api.HealthCheckResponse, error) {
+	return &api.HealthCheckResponse{HealthStatus: "Alive!", ResponseCode: http.StatusOK}, nil
+}
+
+func (m *MemoryCeramic) getStream(id string) (*memoryStream, error) {
+	streamID, err := streams.ParseStreamID(id)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := m.streams[streamID.String()]
+	if !ok {
+		return nil, fmt.Errorf("stream<%s> not found", id)
+	}
+	return stream, nil
+}
+
+// loadState reduces the stream's log, up to the commit named by a commit ID or the last anchor at or before the
+// requested time.
+func (m *MemoryCeramic) loadState(id string, opts *models.LoadOpts) (*streams.StreamState, error) {
+	stream, err := m.getStream(id)
+	if err != nil {
+		return nil, err
+	}
+	commitID, err := streams.ParseCommitID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := stream.commits
+	if strings.TrimPrefix(id, "ceramic://") != stream.id.String() {
+		commitCID := commitID.CommitCID().String()
+		found := false
+		for i, commit := range stream.commits {
+			if commit.CID == commitCID {
+				commits, found = stream.commits[:i+1], true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("commit<%s> not found in stream<%s>", commitCID, stream.id)
+		}
+	}
+	if opts != nil && opts.AtTime > 0 {
+		anchored := 1
+		for i, commit := range commits {
+			if commit.Type == streams.AnchorCommitType && commit.Proof.BlockTimestamp <= opts.AtTime {
+				anchored = i + 1
+			}
+		}
+		commits = commits[:anchored]
+	}
+
+	state, err := streams.ReduceCommits(stream.id.Type, commits)
+	if err != nil {
+		return nil, err
+	}
+	if stream.anchorStatus != streams.NotRequested && len(commits) == len(stream.commits) {
+		state.AnchorStatus = stream.anchorStatus
+	}
+	return state, nil
+}
+
+func (m *MemoryCeramic) appendCommit(stream *memoryStream, record streams.CommitRecord, commit streams.CommitData) (*streams.StreamState, error) {
+	state, err := streams.ReduceCommits(stream.id.Type, append(stream.commits[:len(stream.commits):len(stream.commits)], commit))
+	if err != nil {
+		return nil, err
+	}
+	stream.records = append(stream.records, record)
+	stream.commits = append(stream.commits, commit)
+	stream.anchorStatus = streams.NotRequested
+	return state, nil
+}
+
+func newCommitRecord(commit interface{}) (*streams.CommitRecord, error) {
+	commitBytes, err := json.Marshal(commit)
+	if err != nil {
+		return nil, err
+	}
+	block, err := dagcbor.Encode(commit)
+	if err != nil {
+		return nil, err
+	}
+	commitCID, err := dagcbor.CID(block)
+	if err != nil {
+		return nil, err
+	}
+	value := json.RawMessage(commitBytes)
+	return &streams.CommitRecord{CID: commitCID.String(), Value: &value}, nil
+}
+
+var _ api.CeramicAPI = (*MemoryCeramic)(nil)
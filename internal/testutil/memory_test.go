@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestMemoryCeramic(t *testing.T) {
+	ceramic := NewMemoryCeramic()
+	genesis := map[string]interface{}{
+		"header": map[string]interface{}{
+			"family":      "test",
+			"controllers": []string{"did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H"},
+		},
+		"data": map[string]interface{}{"title": "first"},
+	}
+
+	createResp, err := ceramic.CreateStream(api.CreateStreamRequest{
+		Genesis: genesis,
+		Opts:    models.CreateOpts{PinningOpts: &models.PinningOpts{Pin: true}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, createResp.ResponseCode)
+	streamID := createResp.Response.ID
+
+	t.Run("create is deterministic", func(tt *testing.T) {
+		again, err := ceramic.CreateStream(api.CreateStreamRequest{Genesis: genesis})
+		assert.NoError(tt, err)
+		assert.Equal(tt, streamID, again.Response.ID)
+	})
+
+	t.Run("apply commit", func(tt *testing.T) {
+		commit, err := streams.NewPatchCommit(createResp.Response.State, map[string]string{"title": "second"}, streams.PatchOpts{})
+		assert.NoError(tt, err)
+		applyResp, err := ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: streamID, Commit: commit})
+		assert.NoError(tt, err)
+		assert.Len(tt, applyResp.Response.Log, 2)
+		assert.JSONEq(tt, `{"title":"second"}`, string(*applyResp.Response.Next.Content))
+
+		// the same commit no longer builds on the tip
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: streamID, Commit: commit})
+		assert.Error(tt, err)
+	})
+
+	t.Run("load state and commits", func(tt *testing.T) {
+		stateResp, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID})
+		assert.NoError(tt, err)
+		assert.Len(tt, stateResp.Response.Log, 2)
+
+		id, err := streams.ParseStreamID(streamID)
+		assert.NoError(tt, err)
+		atGenesis, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: id.AtCommit(id.Genesis).String()})
+		assert.NoError(tt, err)
+		assert.Len(tt, atGenesis.Response.Log, 1)
+
+		commitsResp, err := ceramic.GetCommits(api.GetCommitsRequest{StreamID: streamID})
+		assert.NoError(tt, err)
+		assert.Len(tt, commitsResp.Commits, 2)
+		assert.Equal(tt, stateResp.Response.Log[1].CID, commitsResp.Commits[1].CID)
+
+		_, err = ceramic.GetStreamState(api.StreamStateRequest{StreamID: "k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl"})
+		assert.Error(tt, err)
+	})
+
+	t.Run("anchor", func(tt *testing.T) {
+		anchorResp, err := ceramic.RequestAnchor(api.RequestAnchorRequest{StreamID: streamID})
+		assert.NoError(tt, err)
+		assert.Equal(tt, streams.Pending, anchorResp.AnchorStatus)
+
+		proof := streams.AnchorProof{ChainID: "inmemory:12345", BlockNumber: 1, BlockTimestamp: 1000}
+		state, err := ceramic.Anchor(streamID, proof)
+		assert.NoError(tt, err)
+		assert.Equal(tt, streams.Anchored, state.AnchorStatus)
+		assert.Equal(tt, proof, state.AnchorProof)
+		assert.JSONEq(tt, `{"title":"second"}`, string(*state.Content))
+
+		atTime, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID, Opts: &models.LoadOpts{AtTime: 999}})
+		assert.NoError(tt, err)
+		assert.Len(tt, atTime.Response.Log, 1)
+		atTime, err = ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID, Opts: &models.LoadOpts{AtTime: 1000}})
+		assert.NoError(tt, err)
+		assert.Len(tt, atTime.Response.Log, 3)
+
+		// nothing left to anchor
+		assert.Error(tt, ceramic.FailAnchor(streamID))
+	})
+
+	t.Run("pins", func(tt *testing.T) {
+		listResp, err := ceramic.ListStreamsInPinset()
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{streamID}, listResp.PinnedStreamIDs)
+
+		_, err = ceramic.RemoveFromPinset(api.RemoveFromPinsetRequest{StreamID: streamID})
+		assert.NoError(tt, err)
+		confirmResp, err := ceramic.ConfirmStreamInPinset(api.ConfirmStreamInPinsetRequest{StreamID: streamID})
+		assert.NoError(tt, err)
+		assert.Empty(tt, confirmResp.PinnedStreamIDs)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/ed25519"
 	"encoding/json"
 	"errors"
+	"github.com/btcsuite/btcd/btcec/v2"
 	"reflect"
 )
 
@@ -11,6 +12,14 @@ func GenerateEd25519Key() (ed25519.PublicKey, ed25519.PrivateKey, error) {
 	return ed25519.GenerateKey(nil)
 }
 
+func GenerateSecp256k1Key() (*btcec.PublicKey, *btcec.PrivateKey, error) {
+	sk, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk.PubKey(), sk, nil
+}
+
 // Copy makes a 1:1 copy of src into dst.
 func Copy(src interface{}, dst interface{}) error {
 	if err := validateCopy(src, dst); err != nil {
@@ -0,0 +1,25 @@
+package anchor
+
+import (
+	"context"
+)
+
+type Transaction struct {
+	Hash        string
+	BlockNumber uint64
+	To          string
+	Input       []byte
+}
+
+type Block struct {
+	Number    uint64
+	Hash      string
+	Timestamp uint64
+}
+
+// ChainReader gives read access to the blockchains Ceramic anchors to. Chains are identified by their CAIP-2 ID, e.g.
+// eip155:1, and transaction hashes are 0x prefixed hex strings.
+type ChainReader interface {
+	GetTransaction(ctx context.Context, chainID, txHash string) (*Transaction, error)
+	GetBlock(ctx context.Context, chainID string, number uint64) (*Block, error)
+}
@@ -0,0 +1,160 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypeHeader = "Content-Type"
+	contentTypeJSON   = "application/json"
+)
+
+// EthereumReader is a ChainReader backed by Ethereum JSON-RPC endpoints, one per CAIP-2 chain ID.
+type EthereumReader struct {
+	Endpoints map[string]string
+	*http.Client
+}
+
+func NewEthereumReader(endpoints map[string]string) *EthereumReader {
+	return &EthereumReader{
+		Endpoints: endpoints,
+		Client: &http.Client{
+			Timeout: time.Second * 10,
+		},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Result *json.RawMessage `json:"result"`
+	Error  *rpcError        `json:"error"`
+}
+
+type rpcTransaction struct {
+	Hash        string `json:"hash"`
+	BlockNumber string `json:"blockNumber"`
+	To          string `json:"to"`
+	Input       string `json:"input"`
+}
+
+type rpcBlock struct {
+	Number    string `json:"number"`
+	Hash      string `json:"hash"`
+	Timestamp string `json:"timestamp"`
+}
+
+func (r EthereumReader) GetTransaction(ctx context.Context, chainID, txHash string) (*Transaction, error) {
+	var tx rpcTransaction
+	if err := r.call(ctx, chainID, "eth_getTransactionByHash", []interface{}{txHash}, &tx); err != nil {
+		return nil, err
+	}
+	if tx.BlockNumber == "" {
+		return nil, fmt.Errorf("transaction<%s> is not mined", txHash)
+	}
+	blockNumber, err := parseQuantity(tx.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	input, err := hex.DecodeString(strings.TrimPrefix(tx.Input, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction input: %w", err)
+	}
+	return &Transaction{
+		Hash:        tx.Hash,
+		BlockNumber: blockNumber,
+		To:          tx.To,
+		Input:       input,
+	}, nil
+}
+
+func (r EthereumReader) GetBlock(ctx context.Context, chainID string, number uint64) (*Block, error) {
+	var block rpcBlock
+	params := []interface{}{"0x" + strconv.FormatUint(number, 16), false}
+	if err := r.call(ctx, chainID, "eth_getBlockByNumber", params, &block); err != nil {
+		return nil, err
+	}
+	blockNumber, err := parseQuantity(block.Number)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := parseQuantity(block.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &Block{
+		Number:    blockNumber,
+		Hash:      block.Hash,
+		Timestamp: timestamp,
+	}, nil
+}
+
+func (r EthereumReader) call(ctx context.Context, chainID, method string, params []interface{}, result interface{}) error {
+	url, ok := r.Endpoints[chainID]
+	if !ok {
+		return fmt.Errorf("no endpoint configured for chain<%s>", chainID)
+	}
+
+	reqBytes, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(contentTypeHeader, contentTypeJSON)
+
+	resp, err := r.Client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed with status<%d>: %s", method, resp.StatusCode, string(respBytes))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(respBytes, &rpcResp); err != nil {
+		return err
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s failed with code<%d>: %s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	if rpcResp.Result == nil || string(*rpcResp.Result) == "null" {
+		return fmt.Errorf("%s returned no result", method)
+	}
+	return json.Unmarshal(*rpcResp.Result, result)
+}
+
+func parseQuantity(quantity string) (uint64, error) {
+	if !strings.HasPrefix(quantity, "0x") {
+		return 0, fmt.Errorf("invalid quantity: %s", quantity)
+	}
+	return strconv.ParseUint(quantity[2:], 16, 64)
+}
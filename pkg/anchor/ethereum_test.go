@@ -0,0 +1,72 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newRPCServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set(contentTypeHeader, contentTypeJSON)
+		switch req.Method {
+		case "eth_getTransactionByHash":
+			if req.Params[0] != testTxHash {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":null}`)
+				return
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"hash":"%s","blockNumber":"0x919a21","to":"%s","input":"0x%s"}}`,
+				testTxHash, testContract, testInput)
+		case "eth_getBlockByNumber":
+			assert.Equal(t, "0x919a21", req.Params[0])
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{"number":"0x919a21","hash":"0xb10c","timestamp":"0x60104af9"}}`)
+		default:
+			fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+		}
+	}))
+}
+
+func TestEthereumReader(t *testing.T) {
+	server := newRPCServer(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	reader := NewEthereumReader(map[string]string{"eip155:3": server.URL})
+
+	t.Run("get transaction", func(tt *testing.T) {
+		tx, err := reader.GetTransaction(ctx, "eip155:3", testTxHash)
+		assert.NoError(tt, err)
+		assert.Equal(tt, uint64(9542177), tx.BlockNumber)
+		assert.Equal(tt, testContract, tx.To)
+		assert.Equal(tt, testInput, fmt.Sprintf("%x", tx.Input))
+	})
+
+	t.Run("get block", func(tt *testing.T) {
+		block, err := reader.GetBlock(ctx, "eip155:3", 9542177)
+		assert.NoError(tt, err)
+		assert.Equal(tt, uint64(9542177), block.Number)
+		assert.Equal(tt, uint64(1611680505), block.Timestamp)
+	})
+
+	t.Run("missing transaction", func(tt *testing.T) {
+		_, err := reader.GetTransaction(ctx, "eip155:3", "0x00")
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "returned no result")
+	})
+
+	t.Run("unknown chain", func(tt *testing.T) {
+		_, err := reader.GetBlock(ctx, "eip155:1", 1)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "no endpoint configured")
+	})
+
+	t.Run("verify against rpc", func(tt *testing.T) {
+		assert.NoError(tt, NewVerifier(reader, testContracts).Verify(ctx, testProof))
+	})
+}
@@ -0,0 +1,106 @@
+package anchor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"strconv"
+	"strings"
+)
+
+// BlockStore gives access to the raw dag-cbor blocks of the anchor Merkle tree, e.g. backed by an IPFS node.
+type BlockStore interface {
+	Get(ctx context.Context, c cid.Cid) ([]byte, error)
+}
+
+// VerifyMerklePath walks an anchor commit's path from the Merkle root and checks it ends at the commit's prev, which
+// proves the anchored stream tip was included in the batch. Each tree node is a [left, right] array of links and each
+// path segment picks one of them.
+func VerifyMerklePath(ctx context.Context, store BlockStore, root string, commit streams.AnchorCommit) error {
+	current, err := cid.Decode(root)
+	if err != nil {
+		return fmt.Errorf("invalid merkle root<%s>: %w", root, err)
+	}
+	prev, err := cid.Decode(commit.Prev)
+	if err != nil {
+		return fmt.Errorf("invalid anchor commit prev<%s>: %w", commit.Prev, err)
+	}
+
+	for i, segment := range splitPath(commit.Path) {
+		index, err := strconv.Atoi(segment)
+		if err != nil || (index != 0 && index != 1) {
+			return fmt.Errorf("invalid path segment<%s> at position %d of path<%s>", segment, i, commit.Path)
+		}
+		block, err := getBlock(ctx, store, current)
+		if err != nil {
+			return fmt.Errorf("could not load merkle node<%s>: %w", current, err)
+		}
+		node, err := dagcbor.Decode(block)
+		if err != nil {
+			return fmt.Errorf("could not decode merkle node<%s>: %w", current, err)
+		}
+		children, ok := node.([]interface{})
+		if !ok || len(children) <= index {
+			return fmt.Errorf("merkle node<%s> has no child at index %d", current, index)
+		}
+		if current, ok = children[index].(cid.Cid); !ok {
+			return fmt.Errorf("merkle node<%s> child at index %d is not a link", current, index)
+		}
+	}
+
+	if !current.Equals(prev) {
+		return fmt.Errorf("path<%s> leads to<%s>, expected anchor commit prev<%s>", commit.Path, current, commit.Prev)
+	}
+	return nil
+}
+
+// LoadProof reads the anchor proof an anchor commit links to.
+func LoadProof(ctx context.Context, store BlockStore, proof string) (*streams.AnchorProof, error) {
+	proofCID, err := cid.Decode(proof)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof<%s>: %w", proof, err)
+	}
+	block, err := getBlock(ctx, store, proofCID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load proof<%s>: %w", proof, err)
+	}
+	proofJSON, err := dagcbor.DecodeJSON(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode proof<%s>: %w", proof, err)
+	}
+	var anchorProof streams.AnchorProof
+	if err := json.Unmarshal(proofJSON, &anchorProof); err != nil {
+		return nil, fmt.Errorf("could not decode proof<%s>: %w", proof, err)
+	}
+	return &anchorProof, nil
+}
+
+// getBlock loads a dag-cbor block and checks that it hashes to its CID, since the store need not be trusted.
+func getBlock(ctx context.Context, store BlockStore, c cid.Cid) ([]byte, error) {
+	if c.Type() != cid.DagCBOR {
+		return nil, fmt.Errorf("block<%s> is not dag-cbor", c)
+	}
+	block, err := store.Get(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := c.Prefix().Sum(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash block<%s>: %w", c, err)
+	}
+	if !sum.Equals(c) {
+		return nil, fmt.Errorf("block<%s> hashes to<%s>", c, sum)
+	}
+	return block, nil
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
@@ -0,0 +1,135 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type memoryBlockStore map[string][]byte
+
+func (m memoryBlockStore) Get(_ context.Context, c cid.Cid) ([]byte, error) {
+	block, ok := m[c.String()]
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+	return block, nil
+}
+
+func (m memoryBlockStore) put(t *testing.T, v interface{}) cid.Cid {
+	block, err := dagcbor.Encode(v)
+	assert.NoError(t, err)
+	c, err := dagcbor.CID(block)
+	assert.NoError(t, err)
+	m[c.String()] = block
+	return c
+}
+
+func link(c cid.Cid) map[string]string {
+	return map[string]string{"/": c.String()}
+}
+
+// newTestTree builds a tree over four stream tips and returns the root, the tips and the proof
+func newTestTree(t *testing.T, store memoryBlockStore) (cid.Cid, []cid.Cid, cid.Cid) {
+	var tips []cid.Cid
+	for _, content := range []string{"a", "b", "c", "d"} {
+		tips = append(tips, store.put(t, map[string]string{"content": content}))
+	}
+	left := store.put(t, []interface{}{link(tips[0]), link(tips[1])})
+	right := store.put(t, []interface{}{link(tips[2]), link(tips[3])})
+	root := store.put(t, []interface{}{link(left), link(right)})
+	proof := store.put(t, map[string]interface{}{
+		"chainId":        "eip155:3",
+		"blockNumber":    9542177,
+		"blockTimestamp": 1611680505,
+		"root":           link(root),
+		"txHash":         map[string]string{"/": testProof.TxHash},
+	})
+	return root, tips, proof
+}
+
+func TestVerifyMerklePath(t *testing.T) {
+	ctx := context.Background()
+	store := memoryBlockStore{}
+	root, tips, _ := newTestTree(t, store)
+
+	t.Run("valid paths", func(tt *testing.T) {
+		for path, tip := range map[string]cid.Cid{"0/0": tips[0], "0/1": tips[1], "1/0": tips[2], "/1/1": tips[3]} {
+			commit := streams.AnchorCommit{Prev: tip.String(), Path: path}
+			assert.NoError(tt, VerifyMerklePath(ctx, store, root.String(), commit), path)
+		}
+	})
+
+	t.Run("path to a different tip", func(tt *testing.T) {
+		err := VerifyMerklePath(ctx, store, root.String(), streams.AnchorCommit{Prev: tips[0].String(), Path: "1/0"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "expected anchor commit prev")
+	})
+
+	t.Run("empty path", func(tt *testing.T) {
+		assert.NoError(tt, VerifyMerklePath(ctx, store, root.String(), streams.AnchorCommit{Prev: root.String()}))
+	})
+
+	t.Run("bad segment", func(tt *testing.T) {
+		err := VerifyMerklePath(ctx, store, root.String(), streams.AnchorCommit{Prev: tips[0].String(), Path: "0/2"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "invalid path segment")
+	})
+
+	t.Run("path too long", func(tt *testing.T) {
+		err := VerifyMerklePath(ctx, store, root.String(), streams.AnchorCommit{Prev: tips[0].String(), Path: "0/0/0"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "has no child at index")
+	})
+
+	t.Run("missing block", func(tt *testing.T) {
+		err := VerifyMerklePath(ctx, memoryBlockStore{}, root.String(), streams.AnchorCommit{Prev: tips[0].String(), Path: "0/0"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "could not load merkle node")
+	})
+
+	t.Run("tampered block store", func(tt *testing.T) {
+		// a store that answers for the root with a node leading to a tip that was never anchored
+		tampered := memoryBlockStore{}
+		forged := tampered.put(tt, map[string]string{"content": "forged"})
+		for k, v := range store {
+			tampered[k] = v
+		}
+		block, err := dagcbor.Encode([]interface{}{link(forged), link(forged)})
+		assert.NoError(tt, err)
+		tampered[root.String()] = block
+
+		err = VerifyMerklePath(ctx, tampered, root.String(), streams.AnchorCommit{Prev: forged.String(), Path: "0"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "hashes to")
+	})
+}
+
+func TestLoadProof(t *testing.T) {
+	store := memoryBlockStore{}
+	root, _, proof := newTestTree(t, store)
+
+	anchorProof, err := LoadProof(context.Background(), store, proof.String())
+	assert.NoError(t, err)
+	assert.Equal(t, streams.AnchorProof{
+		ChainID:        "eip155:3",
+		BlockNumber:    9542177,
+		BlockTimestamp: 1611680505,
+		TxHash:         testProof.TxHash,
+		Root:           root.String(),
+	}, *anchorProof)
+
+	_, err = LoadProof(context.Background(), store, root.String())
+	assert.Error(t, err)
+
+	tampered := memoryBlockStore{}
+	forged := tampered.put(t, map[string]interface{}{"chainId": "eip155:1", "root": link(root)})
+	tampered[proof.String()] = tampered[forged.String()]
+	_, err = LoadProof(context.Background(), tampered, proof.String())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "hashes to")
+}
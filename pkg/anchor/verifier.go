@@ -0,0 +1,104 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"strings"
+)
+
+// functionSelector is the selector of anchorDagCbor(bytes32), used by the Ceramic anchor contract. Transactions sent
+// to the contract carry the selector followed by the sha2-256 digest of the root.
+var functionSelector = []byte{0x97, 0xad, 0x09, 0xeb}
+
+type Verifier struct {
+	reader ChainReader
+	// contracts is the address of the anchor contract on each CAIP-2 chain
+	contracts map[string]string
+}
+
+// NewVerifier returns a Verifier accepting anchors sent to the given contract address on each CAIP-2 chain ID. Proofs
+// on chains without a contract are rejected.
+func NewVerifier(reader ChainReader, contracts map[string]string) *Verifier {
+	return &Verifier{reader: reader, contracts: contracts}
+}
+
+// Verify checks an anchor proof against the chain: the transaction must exist in the block the proof names, be sent to
+// the chain's anchor contract, its data must commit to the proof's root, and the block must have been mined at the
+// proof's timestamp.
+func (v *Verifier) Verify(ctx context.Context, proof streams.AnchorProof) error {
+	if proof.ChainID == "" || proof.TxHash == "" || proof.Root == "" {
+		return errors.New("anchor proof is missing chain id, transaction hash or root")
+	}
+	root, err := cid.Decode(proof.Root)
+	if err != nil {
+		return fmt.Errorf("invalid anchor root<%s>: %w", proof.Root, err)
+	}
+	contract, ok := v.contracts[proof.ChainID]
+	if !ok {
+		return fmt.Errorf("no anchor contract configured for chain<%s>", proof.ChainID)
+	}
+	txHash, err := TxHashFromCID(proof.TxHash)
+	if err != nil {
+		return err
+	}
+
+	tx, err := v.reader.GetTransaction(ctx, proof.ChainID, txHash)
+	if err != nil {
+		return fmt.Errorf("could not fetch transaction<%s> on chain<%s>: %w", txHash, proof.ChainID, err)
+	}
+	if tx.BlockNumber != proof.BlockNumber {
+		return fmt.Errorf("transaction<%s> is in block<%d>, proof claims block<%d>", txHash, tx.BlockNumber, proof.BlockNumber)
+	}
+	if !strings.EqualFold(tx.To, contract) {
+		return fmt.Errorf("transaction<%s> is sent to<%s>, not the anchor contract<%s>", txHash, tx.To, contract)
+	}
+	if !commitsToRoot(tx.Input, root) {
+		return fmt.Errorf("transaction<%s> data does not commit to root<%s>", txHash, proof.Root)
+	}
+
+	block, err := v.reader.GetBlock(ctx, proof.ChainID, tx.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("could not fetch block<%d> on chain<%s>: %w", tx.BlockNumber, proof.ChainID, err)
+	}
+	if block.Timestamp != proof.BlockTimestamp {
+		return fmt.Errorf("block<%d> has timestamp<%d>, proof claims timestamp<%d>", block.Number, block.Timestamp, proof.BlockTimestamp)
+	}
+	return nil
+}
+
+// TxHashFromCID converts an eth-tx CID, as found in AnchorProof.TxHash, into a 0x prefixed transaction hash. Values
+// that already are hex hashes are returned as is.
+func TxHashFromCID(txHash string) (string, error) {
+	if strings.HasPrefix(txHash, "0x") {
+		return strings.ToLower(txHash), nil
+	}
+	c, err := cid.Decode(txHash)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hash<%s>: %w", txHash, err)
+	}
+	decoded, err := multihash.Decode(c.Hash())
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hash<%s>: %w", txHash, err)
+	}
+	if decoded.Code != multihash.KECCAK_256 {
+		return "", fmt.Errorf("transaction hash<%s> is not a keccak-256 hash", txHash)
+	}
+	return "0x" + hex.EncodeToString(decoded.Digest), nil
+}
+
+func commitsToRoot(input []byte, root cid.Cid) bool {
+	if len(input) != len(functionSelector)+32 || !bytes.Equal(input[:len(functionSelector)], functionSelector) {
+		return false
+	}
+	decoded, err := multihash.Decode(root.Hash())
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(input[len(functionSelector):], decoded.Digest)
+}
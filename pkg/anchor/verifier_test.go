@@ -0,0 +1,154 @@
+package anchor
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// Anchor of stream k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl on ropsten
+var testProof = streams.AnchorProof{
+	ChainID:        "eip155:3",
+	BlockNumber:    9542177,
+	BlockTimestamp: 1611680505,
+	TxHash:         "bagjqcgzabm3nr5eme65yefacrnkaihlhdxooxa6rqfgabbanc5qacarkcxqa",
+	Root:           "bafyreiastagccuwzhjtrvmpxhx62ykswj2377tixkfxhpobze4y3iehjba",
+}
+
+const (
+	testTxHash   = "0x0b36d8f48c27bb8214028b54041d671ddceb83d1814c00840d176001022a15e0"
+	testRootHex  = "0171122012980c2152d93a671ab1f73dfdac2a564eb7ffcd17516e77b8392731b410e908"
+	testInput    = "97ad09eb" + testRootHash
+	testRootHash = "12980c2152d93a671ab1f73dfdac2a564eb7ffcd17516e77b8392731b410e908"
+	testContract = "0x231055a0852d67c7107ad0d0dfeab60278fe6adc"
+)
+
+var testContracts = map[string]string{"eip155:3": testContract}
+
+type fakeChainReader struct {
+	transactions map[string]Transaction
+	blocks       map[uint64]Block
+}
+
+func (f fakeChainReader) GetTransaction(_ context.Context, chainID, txHash string) (*Transaction, error) {
+	tx, ok := f.transactions[chainID+"/"+txHash]
+	if !ok {
+		return nil, errors.New("transaction not found")
+	}
+	return &tx, nil
+}
+
+func (f fakeChainReader) GetBlock(_ context.Context, _ string, number uint64) (*Block, error) {
+	block, ok := f.blocks[number]
+	if !ok {
+		return nil, errors.New("block not found")
+	}
+	return &block, nil
+}
+
+func newFakeChainReader(t *testing.T, input string, timestamp uint64) fakeChainReader {
+	inputBytes, err := hex.DecodeString(input)
+	assert.NoError(t, err)
+	return fakeChainReader{
+		transactions: map[string]Transaction{
+			"eip155:3/" + testTxHash: {Hash: testTxHash, BlockNumber: 9542177, To: testContract, Input: inputBytes},
+		},
+		blocks: map[uint64]Block{
+			9542177: {Number: 9542177, Timestamp: timestamp},
+		},
+	}
+}
+
+func TestTxHashFromCID(t *testing.T) {
+	txHash, err := TxHashFromCID(testProof.TxHash)
+	assert.NoError(t, err)
+	assert.Equal(t, testTxHash, txHash)
+
+	txHash, err = TxHashFromCID("0xABCD")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xabcd", txHash)
+
+	_, err = TxHashFromCID(testProof.Root)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not a keccak-256 hash")
+}
+
+func TestVerifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("anchor contract call", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680505), testContracts)
+		assert.NoError(tt, verifier.Verify(ctx, testProof))
+	})
+
+	t.Run("root cid as transaction data", func(tt *testing.T) {
+		// anchors from before the contract are not supported
+		verifier := NewVerifier(newFakeChainReader(tt, testRootHex, 1611680505), testContracts)
+		err := verifier.Verify(ctx, testProof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "does not commit to root")
+	})
+
+	t.Run("data does not commit to root", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, "97ad09eb"+testRootHash[2:]+"00", 1611680505), testContracts)
+		err := verifier.Verify(ctx, testProof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "does not commit to root")
+	})
+
+	t.Run("wrong timestamp", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680506), testContracts)
+		err := verifier.Verify(ctx, testProof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "proof claims timestamp")
+	})
+
+	t.Run("wrong block", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680505), testContracts)
+		proof := testProof
+		proof.BlockNumber = 1
+		err := verifier.Verify(ctx, proof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "proof claims block")
+	})
+
+	t.Run("unknown transaction", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680505), map[string]string{"eip155:1": testContract})
+		proof := testProof
+		proof.ChainID = "eip155:1"
+		err := verifier.Verify(ctx, proof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "could not fetch transaction")
+	})
+
+	t.Run("not sent to the anchor contract", func(tt *testing.T) {
+		reader := newFakeChainReader(tt, testInput, 1611680505)
+		tx := reader.transactions["eip155:3/"+testTxHash]
+		tx.To = "0x0000000000000000000000000000000000000001"
+		reader.transactions["eip155:3/"+testTxHash] = tx
+		err := NewVerifier(reader, testContracts).Verify(ctx, testProof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "not the anchor contract")
+
+		// addresses are compared regardless of their EIP-55 checksum case
+		tx.To = "0x" + strings.ToUpper(testContract[2:])
+		reader.transactions["eip155:3/"+testTxHash] = tx
+		assert.NoError(tt, NewVerifier(reader, testContracts).Verify(ctx, testProof))
+	})
+
+	t.Run("no contract for chain", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680505), map[string]string{})
+		err := verifier.Verify(ctx, testProof)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "no anchor contract configured")
+	})
+
+	t.Run("incomplete proof", func(tt *testing.T) {
+		verifier := NewVerifier(newFakeChainReader(tt, testInput, 1611680505), testContracts)
+		assert.Error(tt, verifier.Verify(ctx, streams.AnchorProof{ChainID: "eip155:3"}))
+	})
+}
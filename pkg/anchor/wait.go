@@ -0,0 +1,95 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"time"
+)
+
+var (
+	ErrAnchorFailed       = errors.New("anchor failed")
+	ErrAnchorNotRequested = errors.New("no anchor was requested")
+)
+
+// WaitOpts controls how WaitForAnchor polls the node. The interval between polls starts at InitialInterval and
+// doubles after every poll up to MaxInterval.
+type WaitOpts struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// RequestAnchor requests an anchor of a stream whose anchor status is NOT_REQUESTED, rather than failing with
+	// ErrAnchorNotRequested.
+	RequestAnchor bool
+}
+
+var DefaultWaitOpts = WaitOpts{
+	InitialInterval: time.Second,
+	MaxInterval:     time.Minute,
+}
+
+// WaitForAnchor polls the node until the stream's anchor status is ANCHORED or FAILED, or ctx is done. It returns
+// the anchor proof of an anchored stream and an error wrapping ErrAnchorFailed when anchoring failed. A stream with no
+// anchor requested is an error wrapping ErrAnchorNotRequested, unless opts.RequestAnchor is set.
+func WaitForAnchor(ctx context.Context, ceramic api.CeramicAPI, streamID string, opts WaitOpts) (*streams.AnchorProof, error) {
+	interval := opts.InitialInterval
+	if interval <= 0 {
+		interval = DefaultWaitOpts.InitialInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+
+	requested := false
+	for {
+		resp, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID})
+		if err != nil {
+			return nil, fmt.Errorf("could not load stream<%s>: %w", streamID, err)
+		}
+		if resp.ResponseCode < 200 || resp.ResponseCode > 299 {
+			return nil, fmt.Errorf("could not load stream<%s>: status %d", streamID, resp.ResponseCode)
+		}
+		switch resp.Response.AnchorStatus {
+		case streams.Anchored:
+			proof := resp.Response.AnchorProof
+			return &proof, nil
+		case streams.Failed:
+			return nil, fmt.Errorf("stream<%s>: %w", streamID, ErrAnchorFailed)
+		case streams.NotRequested:
+			if !opts.RequestAnchor {
+				return nil, fmt.Errorf("stream<%s>: %w", streamID, ErrAnchorNotRequested)
+			}
+			if !requested {
+				if err := requestAnchor(ceramic, streamID); err != nil {
+					return nil, err
+				}
+				requested = true
+				continue
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("stopped waiting for anchor of stream<%s>: %w", streamID, ctx.Err())
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+func requestAnchor(ceramic api.CeramicAPI, streamID string) error {
+	resp, err := ceramic.RequestAnchor(api.RequestAnchorRequest{StreamID: streamID})
+	if err != nil {
+		return fmt.Errorf("could not request anchor of stream<%s>: %w", streamID, err)
+	}
+	if resp.ResponseCode < 200 || resp.ResponseCode > 299 {
+		return fmt.Errorf("could not request anchor of stream<%s>: status %d", streamID, resp.ResponseCode)
+	}
+	return nil
+}
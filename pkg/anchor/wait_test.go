@@ -0,0 +1,104 @@
+package anchor
+
+import (
+	"context"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// failingCeramic answers every stream state request with an error status, as the HTTP client does.
+type failingCeramic struct {
+	*testutil.MemoryCeramic
+	code int
+}
+
+func (f failingCeramic) GetStreamState(api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	return &api.StreamStateResponse{ResponseCode: f.code}, nil
+}
+
+func TestWaitForAnchor(t *testing.T) {
+	opts := WaitOpts{InitialInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond}
+	proof := streams.AnchorProof{ChainID: "inmemory:12345", BlockNumber: 7, BlockTimestamp: 1000}
+
+	createStream := func(t *testing.T, ceramic *testutil.MemoryCeramic) string {
+		resp, err := ceramic.CreateStream(api.CreateStreamRequest{
+			Genesis: map[string]interface{}{"header": map[string]interface{}{"controllers": []string{"did:key:z6Mk"}}},
+		})
+		assert.NoError(t, err)
+		_, err = ceramic.RequestAnchor(api.RequestAnchorRequest{StreamID: resp.Response.ID})
+		assert.NoError(t, err)
+		return resp.Response.ID
+	}
+
+	t.Run("anchored", func(tt *testing.T) {
+		ceramic := testutil.NewMemoryCeramic()
+		streamID := createStream(tt, ceramic)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_, _ = ceramic.Anchor(streamID, proof)
+		}()
+
+		anchored, err := WaitForAnchor(context.Background(), ceramic, streamID, opts)
+		assert.NoError(tt, err)
+		assert.Equal(tt, proof, *anchored)
+	})
+
+	t.Run("failed", func(tt *testing.T) {
+		ceramic := testutil.NewMemoryCeramic()
+		streamID := createStream(tt, ceramic)
+		assert.NoError(tt, ceramic.FailAnchor(streamID))
+
+		_, err := WaitForAnchor(context.Background(), ceramic, streamID, opts)
+		assert.True(tt, errors.Is(err, ErrAnchorFailed))
+	})
+
+	t.Run("context done", func(tt *testing.T) {
+		ceramic := testutil.NewMemoryCeramic()
+		streamID := createStream(tt, ceramic)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := WaitForAnchor(ctx, ceramic, streamID, opts)
+		assert.True(tt, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("unknown stream", func(tt *testing.T) {
+		_, err := WaitForAnchor(context.Background(), testutil.NewMemoryCeramic(), "k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl", opts)
+		assert.Error(tt, err)
+	})
+
+	t.Run("error status", func(tt *testing.T) {
+		ceramic := failingCeramic{MemoryCeramic: testutil.NewMemoryCeramic(), code: http.StatusInternalServerError}
+		_, err := WaitForAnchor(context.Background(), ceramic, "k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl", opts)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 500")
+	})
+
+	t.Run("not requested", func(tt *testing.T) {
+		ceramic := testutil.NewMemoryCeramic()
+		resp, err := ceramic.CreateStream(api.CreateStreamRequest{
+			Genesis: map[string]interface{}{"header": map[string]interface{}{"controllers": []string{"did:key:z6Mk"}}},
+		})
+		assert.NoError(tt, err)
+		streamID := resp.Response.ID
+
+		_, err = WaitForAnchor(context.Background(), ceramic, streamID, opts)
+		assert.True(tt, errors.Is(err, ErrAnchorNotRequested))
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_, _ = ceramic.Anchor(streamID, proof)
+		}()
+		requestOpts := opts
+		requestOpts.RequestAnchor = true
+		anchored, err := WaitForAnchor(context.Background(), ceramic, streamID, requestOpts)
+		assert.NoError(tt, err)
+		assert.Equal(tt, proof, *anchored)
+	})
+}
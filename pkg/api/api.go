@@ -10,6 +10,7 @@ type CeramicAPI interface {
 
 	GetStreamState(req StreamStateRequest) (*StreamStateResponse, error)
 	CreateStream(req CreateStreamRequest) (*CreateStreamResponse, error)
+	RequestAnchor(req RequestAnchorRequest) (*RequestAnchorResponse, error)
 
 	// MultiqueriesPath //
 
@@ -37,7 +38,8 @@ type CeramicAPI interface {
 // StreamsPath API //
 
 type StreamStateRequest struct {
-	StreamID string `json:"streamId"`
+	StreamID string           `json:"streamId"`
+	Opts     *models.LoadOpts `json:"opts,omitempty"`
 }
 
 type StreamStateResponse struct {
@@ -62,6 +64,16 @@ type CreateStreamResponse struct {
 	ResponseCode int                       `json:"code"`
 }
 
+type RequestAnchorRequest struct {
+	StreamID string `json:"streamId"`
+}
+
+type RequestAnchorResponse struct {
+	StreamID     string               `json:"streamId"`
+	AnchorStatus streams.AnchorStatus `json:"anchorStatus"`
+	ResponseCode int                  `json:"code"`
+}
+
 // MultiqueriesPath API //
 
 type QueryStreamsRequest struct {
@@ -90,9 +102,9 @@ type GetCommitsRequest struct {
 }
 
 type GetCommitsResponse struct {
-	StreamID     string        `json:"streamId"`
-	Commits      []interface{} `json:"streams"`
-	ResponseCode int           `json:"code"`
+	StreamID     string                 `json:"streamId"`
+	Commits      []streams.CommitRecord `json:"commits"`
+	ResponseCode int                    `json:"code"`
 }
 
 type ApplyCommitRequest struct {
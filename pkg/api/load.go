@@ -0,0 +1,29 @@
+package api
+
+import (
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"net/http"
+)
+
+// LoadGenesisStream finds the stream a genesis commit starts. Commits only name their genesis CID, so the stream ID
+// is derived for each stream type in turn until the node knows one.
+func LoadGenesisStream(ceramic CeramicAPI, genesis cid.Cid) (string, *streams.StreamState, error) {
+	var lastErr error
+	for _, streamType := range streams.StreamTypes {
+		streamID := streams.NewStreamID(streamType, genesis).String()
+		resp, err := ceramic.GetStreamState(StreamStateRequest{StreamID: streamID})
+		switch {
+		case err != nil:
+			lastErr = fmt.Errorf("could not load stream<%s>: %w", streamID, err)
+		case resp.ResponseCode == http.StatusNotFound || (resp.ResponseCode == http.StatusOK && len(resp.Response.Log) == 0):
+			lastErr = fmt.Errorf("could not load stream<%s>: status %d", streamID, resp.ResponseCode)
+		case resp.ResponseCode != http.StatusOK:
+			return "", nil, fmt.Errorf("could not load stream<%s>: status %d", streamID, resp.ResponseCode)
+		default:
+			return streamID, &resp.Response, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no stream starts with genesis<%s>: %w", genesis, lastErr)
+}
@@ -0,0 +1,267 @@
+package cacao
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/crypto/sha3"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://github.com/ChainAgnostic/CAIPs/blob/master/CAIPs/caip-74.md
+// https://eips.ethereum.org/EIPS/eip-4361
+// https://eips.ethereum.org/EIPS/eip-191
+
+const (
+	EIP4361 = "eip4361"
+	EIP191  = "eip191"
+
+	// ResourcePrefix prefixes the Ceramic resources a CACAO grants: ceramic://* for every stream,
+	// ceramic://<stream id> for a single stream and ceramic://*?family=<family> for the streams of a family.
+	ResourcePrefix = "ceramic://"
+
+	// IPFSPrefix prefixes the CID of a CACAO in the cap header of a JWS.
+	IPFSPrefix = "ipfs://"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid cacao signature")
+	ErrExpired          = errors.New("cacao has expired")
+	ErrNotYetValid      = errors.New("cacao is not valid yet")
+)
+
+// Cacao is a chain agnostic capability object. A Sign-In With Ethereum message signed by an account delegates the
+// listed resources to the audience, usually a session did:key.
+type Cacao struct {
+	Header    Header    `json:"h"`
+	Payload   Payload   `json:"p"`
+	Signature Signature `json:"s"`
+}
+
+type Header struct {
+	Type string `json:"t"`
+}
+
+type Payload struct {
+	Domain    string   `json:"domain"`
+	Issuer    string   `json:"iss"`
+	Audience  string   `json:"aud"`
+	Version   string   `json:"version"`
+	Nonce     string   `json:"nonce"`
+	IssuedAt  string   `json:"iat"`
+	NotBefore string   `json:"nbf,omitempty"`
+	ExpiresAt string   `json:"exp,omitempty"`
+	Statement string   `json:"statement,omitempty"`
+	RequestID string   `json:"requestId,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+type Signature struct {
+	Type      string `json:"t"`
+	Signature string `json:"s"`
+}
+
+// SIWEMessage renders the payload as the EIP-4361 message the issuer signs. The issuer must be an eip155 did:pkh.
+func (p Payload) SIWEMessage() (string, error) {
+	chainID, address, err := dids.ParsePKHDID(p.Issuer)
+	if err != nil {
+		return "", err
+	}
+	var message strings.Builder
+	message.WriteString(p.Domain + " wants you to sign in with your Ethereum account:\n")
+	message.WriteString(address + "\n\n")
+	if p.Statement != "" {
+		message.WriteString(p.Statement + "\n")
+	}
+	message.WriteString("\nURI: " + p.Audience)
+	message.WriteString("\nVersion: " + p.Version)
+	message.WriteString("\nChain ID: " + chainID)
+	message.WriteString("\nNonce: " + p.Nonce)
+	message.WriteString("\nIssued At: " + p.IssuedAt)
+	if p.ExpiresAt != "" {
+		message.WriteString("\nExpiration Time: " + p.ExpiresAt)
+	}
+	if p.NotBefore != "" {
+		message.WriteString("\nNot Before: " + p.NotBefore)
+	}
+	if p.RequestID != "" {
+		message.WriteString("\nRequest ID: " + p.RequestID)
+	}
+	if len(p.Resources) > 0 {
+		message.WriteString("\nResources:")
+		for _, resource := range p.Resources {
+			message.WriteString("\n- " + resource)
+		}
+	}
+	return message.String(), nil
+}
+
+// SignEIP191 signs the payload as an Ethereum personal message with the issuer's key.
+func SignEIP191(payload Payload, privateKey *btcec.PrivateKey) (*Cacao, error) {
+	message, err := payload.SIWEMessage()
+	if err != nil {
+		return nil, err
+	}
+	compact, err := btcecdsa.SignCompact(privateKey, eip191Hash(message), false)
+	if err != nil {
+		return nil, err
+	}
+	// btcec puts the recovery byte, 27 + recovery id, first; Ethereum puts it last
+	signature := append(compact[1:], compact[0])
+	return &Cacao{
+		Header:    Header{Type: EIP4361},
+		Payload:   payload,
+		Signature: Signature{Type: EIP191, Signature: "0x" + hex.EncodeToString(signature)},
+	}, nil
+}
+
+// Verify checks that the CACAO is signed by its issuer's account and is valid at the given time.
+func (c Cacao) Verify(at time.Time) error {
+	if c.Header.Type != EIP4361 {
+		return fmt.Errorf("unsupported cacao type<%s>", c.Header.Type)
+	}
+	if c.Signature.Type != EIP191 {
+		return fmt.Errorf("unsupported cacao signature type<%s>", c.Signature.Type)
+	}
+	if err := c.checkTimes(at); err != nil {
+		return err
+	}
+	return c.VerifySignature()
+}
+
+// VerifySignature checks that the CACAO is signed by its issuer's account, regardless of when it is valid.
+func (c Cacao) VerifySignature() error {
+	if c.Header.Type != EIP4361 {
+		return fmt.Errorf("unsupported cacao type<%s>", c.Header.Type)
+	}
+	if c.Signature.Type != EIP191 {
+		return fmt.Errorf("unsupported cacao signature type<%s>", c.Signature.Type)
+	}
+	_, address, err := dids.ParsePKHDID(c.Payload.Issuer)
+	if err != nil {
+		return err
+	}
+	message, err := c.Payload.SIWEMessage()
+	if err != nil {
+		return err
+	}
+	signer, err := recoverAddress(message, c.Signature.Signature)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(signer, address) {
+		return fmt.Errorf("%w: signed by<%s>, issuer is<%s>", ErrInvalidSignature, signer, address)
+	}
+	return nil
+}
+
+func (c Cacao) checkTimes(at time.Time) error {
+	if _, err := time.Parse(time.RFC3339, c.Payload.IssuedAt); err != nil {
+		return fmt.Errorf("invalid cacao issued at<%s>: %w", c.Payload.IssuedAt, err)
+	}
+	if c.Payload.NotBefore != "" {
+		notBefore, err := time.Parse(time.RFC3339, c.Payload.NotBefore)
+		if err != nil {
+			return fmt.Errorf("invalid cacao not before<%s>: %w", c.Payload.NotBefore, err)
+		}
+		if at.Before(notBefore) {
+			return ErrNotYetValid
+		}
+	}
+	if c.Payload.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, c.Payload.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("invalid cacao expiration<%s>: %w", c.Payload.ExpiresAt, err)
+		}
+		if !at.Before(expiresAt) {
+			return ErrExpired
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the CACAO grants access to the stream, given its ID and family. Either may be empty when it
+// is not known, such as the ID of a stream that has not been created yet.
+func (c Cacao) Allows(streamID, family string) bool {
+	for _, resource := range c.Payload.Resources {
+		switch {
+		case resource == ResourcePrefix+"*":
+			return true
+		case streamID != "" && resource == ResourcePrefix+streamID:
+			return true
+		case family != "" && resource == ResourcePrefix+"*?family="+family:
+			return true
+		}
+	}
+	return false
+}
+
+// GrantsFamilies reports whether any of the CACAO's resources is limited to a family rather than named streams.
+func (c Cacao) GrantsFamilies() bool {
+	for _, resource := range c.Payload.Resources {
+		if strings.HasPrefix(resource, ResourcePrefix+"*?family=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode encodes the CACAO as a dag-cbor block, the form JWS cap headers refer to.
+func (c Cacao) Encode() ([]byte, error) {
+	return dagcbor.Encode(c)
+}
+
+// CID returns the CID of the CACAO's dag-cbor block.
+func (c Cacao) CID() (cid.Cid, error) {
+	block, err := c.Encode()
+	if err != nil {
+		return cid.Undef, err
+	}
+	return dagcbor.CID(block)
+}
+
+// Decode decodes a CACAO from its dag-cbor block.
+func Decode(block []byte) (*Cacao, error) {
+	cacaoJSON, err := dagcbor.DecodeJSON(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cacao: %w", err)
+	}
+	var c Cacao
+	if err := json.Unmarshal(cacaoJSON, &c); err != nil {
+		return nil, fmt.Errorf("could not decode cacao: %w", err)
+	}
+	return &c, nil
+}
+
+// eip191Hash hashes a message as an Ethereum personal message.
+func eip191Hash(message string) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte("\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message)) + message))
+	return hash.Sum(nil)
+}
+
+func recoverAddress(message, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	// the recovery byte is 27 or 28, or 0 or 1 from some wallets
+	recovery := sig[64]
+	if recovery < 27 {
+		recovery += 27
+	}
+	compact := append([]byte{recovery}, sig[:64]...)
+	publicKey, _, err := btcecdsa.RecoverCompact(compact, eip191Hash(message))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	return dids.EthereumAddress(publicKey), nil
+}
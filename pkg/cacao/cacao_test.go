@@ -0,0 +1,104 @@
+package cacao
+
+import (
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSIWEMessage(t *testing.T) {
+	// https://eips.ethereum.org/EIPS/eip-4361#example-message
+	payload := Payload{
+		Domain:    "service.invalid",
+		Issuer:    "did:pkh:eip155:1:0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		Audience:  "https://service.invalid/login",
+		Version:   "1",
+		Nonce:     "32891756",
+		IssuedAt:  "2021-09-30T16:25:24Z",
+		Statement: "I accept the ServiceOrg Terms of Service: https://service.invalid/tos",
+		Resources: []string{
+			"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/",
+			"https://example.com/my-web2-claim.json",
+		},
+	}
+	message, err := payload.SIWEMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`, message)
+
+	payload.Statement, payload.Resources = "", nil
+	payload.ExpiresAt = "2021-10-30T16:25:24Z"
+	message, err = payload.SIWEMessage()
+	assert.NoError(t, err)
+	assert.Contains(t, message, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: ")
+	assert.Contains(t, message, "\nExpiration Time: 2021-10-30T16:25:24Z")
+}
+
+func TestCacao(t *testing.T) {
+	publicKey, privateKey, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	issuer := dids.CreatePKHDID("1", dids.EthereumAddress(publicKey))
+	payload := Payload{
+		Domain:    "app.example",
+		Issuer:    issuer,
+		Audience:  "did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H",
+		Version:   "1",
+		Nonce:     "abcdef",
+		IssuedAt:  "2022-01-01T00:00:00Z",
+		ExpiresAt: "2022-01-02T00:00:00Z",
+		Resources: []string{"ceramic://*?family=notes", "ceramic://kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly"},
+	}
+	c, err := SignEIP191(payload, privateKey)
+	assert.NoError(t, err)
+	during := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("verify", func(tt *testing.T) {
+		assert.NoError(tt, c.Verify(during))
+		assert.True(tt, errors.Is(c.Verify(during.Add(24*time.Hour)), ErrExpired))
+
+		notYet := *c
+		notYet.Payload.NotBefore = "2022-01-01T18:00:00Z"
+		assert.True(tt, errors.Is(notYet.Verify(during), ErrNotYetValid))
+
+		tampered := *c
+		tampered.Payload.Resources = []string{"ceramic://*"}
+		assert.True(tt, errors.Is(tampered.Verify(during), ErrInvalidSignature))
+
+		_, otherKey, err := internal.GenerateSecp256k1Key()
+		assert.NoError(tt, err)
+		forged, err := SignEIP191(payload, otherKey)
+		assert.NoError(tt, err)
+		assert.True(tt, errors.Is(forged.Verify(during), ErrInvalidSignature))
+	})
+
+	t.Run("resources", func(tt *testing.T) {
+		assert.True(tt, c.Allows("", "notes"))
+		assert.True(tt, c.Allows("kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly", ""))
+		assert.False(tt, c.Allows("kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktlz", "secrets"))
+		assert.False(tt, c.Allows("", ""))
+		assert.True(tt, c.GrantsFamilies())
+	})
+
+	t.Run("encode", func(tt *testing.T) {
+		block, err := c.Encode()
+		assert.NoError(tt, err)
+		decoded, err := Decode(block)
+		assert.NoError(tt, err)
+		assert.Equal(tt, c, decoded)
+		assert.NoError(tt, decoded.Verify(during))
+	})
+}
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
 	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,8 +19,9 @@ const (
 	ClayTestnet      = "https://ceramic-clay.3boxlabs.com"
 	V0Path           = "api/v0"
 	StreamsPath      = "streams"
+	AnchorPath       = "anchor"
 	MultiqueriesPath = "multiqueries"
-	CommitsPath      = "streams"
+	CommitsPath      = "commits"
 	PinsPath         = "pins"
 	NodePath         = "node"
 	ChainsPath       = "chains"
@@ -46,6 +50,9 @@ func NewCeramicClient(host, base string) *CeramicClient {
 
 func (c CeramicClient) GetStreamState(req api.StreamStateRequest) (*api.StreamStateResponse, error) {
 	url := strings.Join([]string{c.Host, c.BasePath, StreamsPath, req.StreamID}, "/")
+	if query := loadQuery(req.Opts); query != "" {
+		url += "?" + query
+	}
 	resp, err := c.Get(url)
 	if err != nil {
 		return nil, err
@@ -57,17 +64,43 @@ func (c CeramicClient) GetStreamState(req api.StreamStateRequest) (*api.StreamSt
 		return nil, err
 	}
 
-	var data streams.StreamState
+	var data streams.StreamStateHolder
 	if err := json.Unmarshal(respBytes, &data); err != nil {
 		return nil, err
 	}
 
 	return &api.StreamStateResponse{
-		Response:     data,
+		Response:     data.State,
 		ResponseCode: resp.StatusCode,
 	}, nil
 }
 
+// loadQuery encodes load options as query parameters. The node numbers its sync modes differently than
+// models.SyncOptions: 0 prefers the cache, 1 always syncs and 2 never syncs.
+func loadQuery(opts *models.LoadOpts) string {
+	if opts == nil {
+		return ""
+	}
+	query := url.Values{}
+	if opts.SyncOpts != nil {
+		switch opts.Sync {
+		case models.PreferCache:
+			query.Set("sync", "0")
+		case models.SyncAlways:
+			query.Set("sync", "1")
+		case models.NeverSync:
+			query.Set("sync", "2")
+		}
+		if opts.SyncTimeoutSeconds > 0 {
+			query.Set("syncTimeoutSeconds", strconv.FormatUint(opts.SyncTimeoutSeconds, 10))
+		}
+	}
+	if opts.AtTime > 0 {
+		query.Set("atTime", strconv.FormatUint(opts.AtTime, 10))
+	}
+	return query.Encode()
+}
+
 func (c CeramicClient) CreateStream(req api.CreateStreamRequest) (*api.CreateStreamResponse, error) {
 	url := strings.Join([]string{c.Host, c.BasePath, StreamsPath}, "/")
 
@@ -104,6 +137,31 @@ func (c CeramicClient) CreateStream(req api.CreateStreamRequest) (*api.CreateStr
 	}, nil
 }
 
+func (c CeramicClient) RequestAnchor(req api.RequestAnchorRequest) (*api.RequestAnchorResponse, error) {
+	url := strings.Join([]string{c.Host, c.BasePath, StreamsPath, req.StreamID, AnchorPath}, "/")
+	resp, err := c.Post(url, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// an error body would decode to the zero AnchorStatus, NotRequested
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("could not request anchor of stream<%s>: status %d: %s", req.StreamID, resp.StatusCode, strings.TrimSpace(string(respBytes)))
+	}
+	requestAnchorResp := api.RequestAnchorResponse{ResponseCode: resp.StatusCode}
+	if err := json.Unmarshal(respBytes, &requestAnchorResp); err != nil {
+		return nil, err
+	}
+
+	return &requestAnchorResp, nil
+}
+
 func (c CeramicClient) QueryStream(req api.QueryStreamRequest) (*api.QueryStreamResponse, error) {
 	resp, err := c.QueryStreams(api.QueryStreamsRequest{Queries: []api.QueryStreamRequest{req}})
 	if err != nil {
@@ -203,13 +261,13 @@ func (c CeramicClient) ApplyCommit(req api.ApplyCommitRequest) (*api.ApplyCommit
 		return nil, err
 	}
 
-	var data streams.StreamState
+	var data streams.StreamStateHolder
 	if err := json.Unmarshal(respBytes, &data); err != nil {
 		return nil, err
 	}
 
 	return &api.ApplyCommitResponse{
-		Response:     data,
+		Response:     data.State,
 		ResponseCode: resp.StatusCode,
 	}, nil
 }
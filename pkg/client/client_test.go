@@ -3,8 +3,10 @@ package client
 import (
 	"fmt"
 	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -43,7 +45,31 @@ func TestMultiqueries(t *testing.T) {
 }
 
 func TestCommits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/"+V0Path+"/"+CommitsPath+"/kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly", r.URL.Path)
+		_, _ = w.Write([]byte(`{"streamId":"kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly","commits":[{"cid":"bafyreigenesis","value":{"header":{"controllers":["did:key:z6Mk"]}}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewCeramicClient(server.URL, V0Path)
+	resp, err := client.GetCommits(api.GetCommitsRequest{StreamID: "kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.ResponseCode)
+	assert.Len(t, resp.Commits, 1)
+	assert.Equal(t, "bafyreigenesis", resp.Commits[0].CID)
+	assert.JSONEq(t, `{"header":{"controllers":["did:key:z6Mk"]}}`, string(*resp.Commits[0].Value))
+}
+
+func TestRequestAnchor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "stream not found", http.StatusNotFound)
+	}))
+	defer server.Close()
 
+	client := NewCeramicClient(server.URL, V0Path)
+	_, err := client.RequestAnchor(api.RequestAnchorRequest{StreamID: "kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404: stream not found")
 }
 
 func TestPins(t *testing.T) {
@@ -70,3 +96,12 @@ func TestNodeInfo(t *testing.T) {
 		assert.Equal(tt, "Alive!", resp.HealthStatus)
 	})
 }
+
+func TestLoadQuery(t *testing.T) {
+	assert.Empty(t, loadQuery(nil))
+	assert.Equal(t, "sync=0", loadQuery(&models.LoadOpts{SyncOpts: &models.SyncOpts{Sync: models.PreferCache}}))
+	assert.Equal(t, "atTime=1611680505&sync=1&syncTimeoutSeconds=3", loadQuery(&models.LoadOpts{
+		SyncOpts: &models.SyncOpts{Sync: models.SyncAlways, SyncTimeoutSeconds: 3},
+		AtTime:   1611680505,
+	}))
+}
@@ -0,0 +1,237 @@
+package dids
+
+import (
+	"container/list"
+	"github.com/textileio/go-did-resolver/resolver"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Forever is a cache TTL that never expires.
+	Forever = time.Duration(math.MaxInt64)
+
+	// DefaultCacheSize is the number of results a CachingResolver holds before evicting the least recently used.
+	DefaultCacheSize = 10000
+
+	// sweepInterval is how often expired entries are dropped, even if they are never read again.
+	sweepInterval = time.Minute
+)
+
+// CachePolicy controls how long the results of resolving DIDs of one method are cached.
+type CachePolicy struct {
+	// TTL is how long a resolved document is cached. Zero disables caching.
+	TTL time.Duration
+	// NegativeTTL is how long a failed resolution is cached, so that a bad DID does not hit the network on every use.
+	NegativeTTL time.Duration
+	// VersionedTTL is how long a document resolved at a specific version is cached. Documents resolved without a
+	// version are also cached under the version they were resolved at.
+	VersionedTTL time.Duration
+}
+
+var (
+	// DefaultCachePolicy applies to methods without a policy of their own.
+	DefaultCachePolicy = CachePolicy{TTL: time.Minute, NegativeTTL: 10 * time.Second}
+
+	// DefaultCachePolicies cache did:key documents, which are derived from the DID itself, forever, and did:3
+	// documents at a version forever. Failures are only cached briefly.
+	DefaultCachePolicies = map[string]CachePolicy{
+		"key": {TTL: Forever, NegativeTTL: 10 * time.Second},
+		"3":   {TTL: time.Minute, NegativeTTL: 10 * time.Second, VersionedTTL: Forever},
+	}
+)
+
+type cacheEntry struct {
+	key      string
+	resolved *ResolvedDID
+	err      error
+	expires  time.Time
+}
+
+// CachingResolver caches the results of another resolver according to per method policies. It holds at most a fixed
+// number of results, evicting the least recently used.
+type CachingResolver struct {
+	resolver DIDResolver
+	policies map[string]CachePolicy
+	size     int
+	now      func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	recent    *list.List
+	lastSweep time.Time
+}
+
+// NewCachingResolver wraps resolver with a cache of DefaultCacheSize results. If policies is nil,
+// DefaultCachePolicies are used.
+func NewCachingResolver(resolver DIDResolver, policies map[string]CachePolicy) *CachingResolver {
+	return NewCachingResolverWithSize(resolver, policies, DefaultCacheSize)
+}
+
+// NewCachingResolverWithSize wraps resolver with a cache of at most size results.
+func NewCachingResolverWithSize(resolver DIDResolver, policies map[string]CachePolicy, size int) *CachingResolver {
+	if policies == nil {
+		policies = DefaultCachePolicies
+	}
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &CachingResolver{
+		resolver: resolver,
+		policies: policies,
+		size:     size,
+		now:      time.Now,
+		entries:  make(map[string]*list.Element),
+		recent:   list.New(),
+	}
+}
+
+func (c *CachingResolver) Resolve(did string) (*ResolvedDID, error) {
+	key := cacheKey(did)
+	now := c.now()
+
+	c.mu.Lock()
+	var entry *cacheEntry
+	if element, ok := c.entries[key]; ok {
+		entry = element.Value.(*cacheEntry)
+		if now.After(entry.expires) {
+			c.remove(element)
+			entry = nil
+		} else {
+			c.recent.MoveToFront(element)
+		}
+	}
+	c.mu.Unlock()
+	if entry != nil {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return copyResolved(entry.resolved), nil
+	}
+
+	resolved, err := c.resolver.Resolve(did)
+	policy := c.policy(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case err != nil:
+		c.store(key, cacheEntry{err: err}, now, policy.NegativeTTL)
+	case isVersioned(key):
+		c.store(key, cacheEntry{resolved: resolved}, now, policy.VersionedTTL)
+	default:
+		c.store(key, cacheEntry{resolved: resolved}, now, policy.TTL)
+		if resolved.VersionID != "" {
+			c.store(versionKey(key, resolved.VersionID), cacheEntry{resolved: resolved}, now, policy.VersionedTTL)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return copyResolved(resolved), nil
+}
+
+// Purge drops the cached results for did, such as after seeing an update to its document.
+func (c *CachingResolver) Purge(did string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[cacheKey(did)]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *CachingResolver) policy(key string) CachePolicy {
+	parts := strings.SplitN(key, ":", 3)
+	if len(parts) == 3 {
+		if policy, ok := c.policies[parts[1]]; ok {
+			return policy
+		}
+	}
+	return DefaultCachePolicy
+}
+
+func (c *CachingResolver) store(key string, entry cacheEntry, now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if ttl == Forever || now.Add(ttl).Before(now) {
+		entry.expires = time.Unix(1<<62, 0)
+	} else {
+		entry.expires = now.Add(ttl)
+	}
+	entry.key = key
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if entry.resolved != nil {
+		entry.resolved = copyResolved(entry.resolved)
+	}
+	c.entries[key] = c.recent.PushFront(&entry)
+
+	if now.Sub(c.lastSweep) >= sweepInterval || len(c.entries) > c.size {
+		c.sweep(now)
+	}
+	for len(c.entries) > c.size {
+		c.remove(c.recent.Back())
+	}
+}
+
+// sweep drops the expired entries.
+func (c *CachingResolver) sweep(now time.Time) {
+	c.lastSweep = now
+	for element := c.recent.Front(); element != nil; {
+		next := element.Next()
+		if now.After(element.Value.(*cacheEntry).expires) {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+func (c *CachingResolver) remove(element *list.Element) {
+	c.recent.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// copyResolved copies a result deeply enough that callers cannot change a cached document.
+func copyResolved(resolved *ResolvedDID) *ResolvedDID {
+	copied := *resolved
+	copied.Context = append([]string(nil), resolved.Context...)
+	copied.Controller = append([]string(nil), resolved.Controller...)
+	copied.VerificationMethod = copyMethods(resolved.VerificationMethod)
+	copied.AssertionMethod = copyMethods(resolved.AssertionMethod)
+	copied.Authentication = copyMethods(resolved.Authentication)
+	copied.CapabilityInvocation = copyMethods(resolved.CapabilityInvocation)
+	copied.CapabilityDelegation = copyMethods(resolved.CapabilityDelegation)
+	copied.KeyAgreement = copyMethods(resolved.KeyAgreement)
+	copied.Service = append([]resolver.ServiceEndpoint(nil), resolved.Service...)
+	return &copied
+}
+
+func copyMethods(methods []VerificationMethod) []VerificationMethod {
+	copied := append([]VerificationMethod(nil), methods...)
+	for i := range copied {
+		if copied[i].PublicKeyJwk != nil {
+			jwk := *copied[i].PublicKeyJwk
+			copied[i].PublicKeyJwk = &jwk
+		}
+	}
+	return copied
+}
+
+// cacheKey drops the fragment of a DID URL, which names a part of the document rather than a different document.
+func cacheKey(did string) string {
+	return strings.SplitN(did, "#", 2)[0]
+}
+
+func isVersioned(key string) bool {
+	parts := strings.SplitN(key, "?", 2)
+	return len(parts) == 2 && strings.Contains(parts[1], "versionId=")
+}
+
+func versionKey(key, versionID string) string {
+	return strings.SplitN(key, "?", 2)[0] + "?versionId=" + versionID
+}
+
+var _ DIDResolver = (*CachingResolver)(nil)
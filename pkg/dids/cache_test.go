@@ -0,0 +1,157 @@
+package dids
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/textileio/go-did-resolver/resolver"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	calls   map[string]int
+	version string
+	fail    bool
+}
+
+func (r *countingResolver) Resolve(did string) (*ResolvedDID, error) {
+	r.calls[did]++
+	if r.fail {
+		return nil, errors.New("resolution failed")
+	}
+	return &ResolvedDID{
+		Document:         Document{Context: []string{"https://w3id.org/did/v1"}, ID: cacheKey(did)},
+		DocumentMetadata: DocumentMetadata{DocumentMetadata: resolver.DocumentMetadata{VersionID: r.version}},
+	}, nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	const (
+		keyDID   = "did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H"
+		threeDID = "did:3:kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly"
+	)
+
+	newCache := func(upstream *countingResolver) (*CachingResolver, *time.Time) {
+		now := time.Unix(1600000000, 0)
+		cache := NewCachingResolver(upstream, nil)
+		cache.now = func() time.Time { return now }
+		return cache, &now
+	}
+
+	t.Run("did:key is cached forever", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}}
+		cache, now := newCache(upstream)
+		for i := 0; i < 3; i++ {
+			resolved, err := cache.Resolve(keyDID + "#key")
+			assert.NoError(tt, err)
+			assert.Equal(tt, keyDID, resolved.ID)
+			*now = now.Add(24 * 365 * time.Hour)
+		}
+		assert.Equal(tt, 1, upstream.calls[keyDID+"#key"])
+
+		cache.Purge(keyDID)
+		_, err := cache.Resolve(keyDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, 1, upstream.calls[keyDID])
+	})
+
+	t.Run("did:3 expires", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}, version: "bafyversion"}
+		cache, now := newCache(upstream)
+		_, err := cache.Resolve(threeDID)
+		assert.NoError(tt, err)
+		*now = now.Add(30 * time.Second)
+		_, err = cache.Resolve(threeDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, 1, upstream.calls[threeDID])
+
+		*now = now.Add(time.Minute)
+		_, err = cache.Resolve(threeDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, 2, upstream.calls[threeDID])
+
+		// the latest document was also cached at its version, which never changes
+		*now = now.Add(24 * time.Hour)
+		resolved, err := cache.Resolve(threeDID + "?versionId=bafyversion")
+		assert.NoError(tt, err)
+		assert.Equal(tt, threeDID, resolved.ID)
+		assert.Zero(tt, upstream.calls[threeDID+"?versionId=bafyversion"])
+	})
+
+	t.Run("failures are cached briefly", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}, fail: true}
+		cache, now := newCache(upstream)
+		_, err := cache.Resolve(threeDID)
+		assert.Error(tt, err)
+		_, err = cache.Resolve(threeDID)
+		assert.Error(tt, err)
+		assert.Equal(tt, 1, upstream.calls[threeDID])
+
+		upstream.fail = false
+		*now = now.Add(11 * time.Second)
+		_, err = cache.Resolve(threeDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, 2, upstream.calls[threeDID])
+	})
+
+	t.Run("custom policies", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}}
+		cache := NewCachingResolver(upstream, map[string]CachePolicy{"key": {}})
+		for i := 0; i < 2; i++ {
+			_, err := cache.Resolve(keyDID)
+			assert.NoError(tt, err)
+			_, err = cache.Resolve("did:web:example.com")
+			assert.NoError(tt, err)
+		}
+		// caching is disabled for did:key, other methods use the default policy
+		assert.Equal(tt, 2, upstream.calls[keyDID])
+		assert.Equal(tt, 1, upstream.calls["did:web:example.com"])
+	})
+
+	t.Run("did:key failures expire", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}, fail: true}
+		cache, now := newCache(upstream)
+		_, err := cache.Resolve(keyDID)
+		assert.Error(tt, err)
+
+		upstream.fail = false
+		*now = now.Add(11 * time.Second)
+		_, err = cache.Resolve(keyDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, 2, upstream.calls[keyDID])
+	})
+
+	t.Run("results are copies", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}}
+		cache, _ := newCache(upstream)
+		resolved, err := cache.Resolve(keyDID)
+		assert.NoError(tt, err)
+		resolved.Context[0] = "tampered"
+
+		resolved, err = cache.Resolve(keyDID)
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{"https://w3id.org/did/v1"}, resolved.Context)
+	})
+
+	t.Run("size is bounded", func(tt *testing.T) {
+		upstream := &countingResolver{calls: map[string]int{}}
+		cache := NewCachingResolverWithSize(upstream, nil, 2)
+		now := time.Unix(1600000000, 0)
+		cache.now = func() time.Time { return now }
+
+		for _, query := range []string{"?a", "?b", "?c"} {
+			_, err := cache.Resolve(threeDID + query)
+			assert.NoError(tt, err)
+		}
+		assert.Len(tt, cache.entries, 2)
+		_, err := cache.Resolve(threeDID + "?a")
+		assert.NoError(tt, err)
+		assert.Equal(tt, 2, upstream.calls[threeDID+"?a"])
+
+		// expired entries are swept even if they are never read again
+		now = now.Add(2 * time.Minute)
+		_, err = cache.Resolve(keyDID)
+		assert.NoError(tt, err)
+		assert.Len(tt, cache.entries, 1)
+	})
+}
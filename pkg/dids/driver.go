@@ -0,0 +1,204 @@
+package dids
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// https://w3c-ccg.github.io/did-resolution/#bindings-https
+// https://github.com/decentralized-identity/universal-resolver/blob/main/docs/driver-development.md
+
+const (
+	// IdentifiersPath is the path under which a Driver resolves DIDs: GET /1.0/identifiers/{did}.
+	IdentifiersPath = "/1.0/identifiers/"
+
+	DIDJSONContentType   = "application/did+json"
+	DIDLDJSONContentType = "application/did+ld+json"
+	// ResolutionResultContentType is the content type of a full resolution result, with its metadata.
+	ResolutionResultContentType = `application/ld+json;profile="https://w3id.org/did-resolution"`
+
+	resolutionResultProfile = "https://w3id.org/did-resolution"
+	resolutionContext       = "https://w3id.org/did-resolution/v1"
+)
+
+// ResolutionResult is the body a Driver returns unless only the DID document is asked for.
+type ResolutionResult struct {
+	Context               string                 `json:"@context"`
+	DIDDocument           interface{}            `json:"didDocument"`
+	DIDResolutionMetadata DIDResolutionMetadata  `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   map[string]interface{} `json:"didDocumentMetadata"`
+}
+
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// Driver is an http.Handler serving a DIDResolver as a Universal Resolver driver. Depending on the Accept header it
+// returns the DID document alone or the full resolution result, and it maps resolution errors to HTTP statuses.
+type Driver struct {
+	resolver DIDResolver
+}
+
+func NewDriver(resolver DIDResolver) *Driver {
+	return &Driver{resolver: resolver}
+}
+
+func (d *Driver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.EscapedPath(), IdentifiersPath) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		writeResolutionError(w, RepresentationNotSupportedError, "no supported representation is acceptable")
+		return
+	}
+	did, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), IdentifiersPath))
+	if err != nil || did == "" {
+		writeResolutionError(w, InvalidDIDError, "could not read did from path")
+		return
+	}
+	if r.URL.RawQuery != "" {
+		did += "?" + r.URL.RawQuery
+	}
+
+	resolved, err := d.resolver.Resolve(did)
+	if err != nil {
+		code := InternalError
+		var resolutionErr *ResolutionError
+		if errors.As(err, &resolutionErr) {
+			code = resolutionErr.Code
+		}
+		writeResolutionError(w, code, err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if resolved.Deactivated {
+		status = http.StatusGone
+	}
+	if contentType != ResolutionResultContentType {
+		writeBody(w, status, contentType, withoutLDContext(resolved.Document, contentType))
+		return
+	}
+	documentMetadata, err := toMap(resolved.DocumentMetadata)
+	if err != nil {
+		writeResolutionError(w, InternalError, err.Error())
+		return
+	}
+	writeBody(w, status, contentType, ResolutionResult{
+		Context:               resolutionContext,
+		DIDDocument:           resolved.Document,
+		DIDResolutionMetadata: DIDResolutionMetadata{ContentType: DIDLDJSONContentType},
+		DIDDocumentMetadata:   documentMetadata,
+	})
+}
+
+// negotiate picks the representation to return for an Accept header, by the q values of its media ranges and then
+// their order. The resolution result is preferred when the client accepts anything.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ResolutionResultContentType, true
+	}
+	type mediaRange struct {
+		contentType string
+		q           float64
+	}
+	var ranges []mediaRange
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(value)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qValue, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qValue, 64); err != nil || q <= 0 || q > 1 {
+				continue
+			}
+		}
+		switch mediaType {
+		case DIDLDJSONContentType, DIDJSONContentType:
+			ranges = append(ranges, mediaRange{mediaType, q})
+		case "application/ld+json":
+			if profile, ok := params["profile"]; !ok || profile == resolutionResultProfile {
+				ranges = append(ranges, mediaRange{ResolutionResultContentType, q})
+			}
+		case "application/json", "application/*", "*/*":
+			ranges = append(ranges, mediaRange{ResolutionResultContentType, q})
+		}
+	}
+	if len(ranges) == 0 {
+		return "", false
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges[0].contentType, true
+}
+
+// withoutLDContext drops the @context of a document returned as plain JSON.
+func withoutLDContext(document interface{}, contentType string) interface{} {
+	if contentType != DIDJSONContentType {
+		return document
+	}
+	documentMap, err := toMap(document)
+	if err != nil {
+		return document
+	}
+	delete(documentMap, "@context")
+	return documentMap
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func resolutionStatus(code string) int {
+	switch code {
+	case InvalidDIDError:
+		return http.StatusBadRequest
+	case NotFoundError:
+		return http.StatusNotFound
+	case MethodNotSupportedError:
+		return http.StatusNotImplemented
+	case RepresentationNotSupportedError:
+		return http.StatusNotAcceptable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeResolutionError(w http.ResponseWriter, code, message string) {
+	writeBody(w, resolutionStatus(code), ResolutionResultContentType, ResolutionResult{
+		Context:               resolutionContext,
+		DIDResolutionMetadata: DIDResolutionMetadata{Error: code, Message: message},
+		DIDDocumentMetadata:   map[string]interface{}{},
+	})
+}
+
+func writeBody(w http.ResponseWriter, status int, contentType string, body interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,146 @@
+package dids
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	parse "github.com/ockam-network/did"
+	"github.com/stretchr/testify/assert"
+	"github.com/textileio/go-did-resolver/resolver"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type stubResolver struct {
+	err error
+}
+
+func (s stubResolver) Resolve(string) (*ResolvedDID, error) {
+	return nil, s.err
+}
+
+// failingMethod fails to resolve every DID of its method, as a method resolver does when its node is unreachable.
+type failingMethod struct{}
+
+func (failingMethod) Method() string {
+	return "fail"
+}
+
+func (failingMethod) Resolve(string, *parse.DID, resolver.Resolver) (*resolver.Document, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestDriver(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	server := httptest.NewServer(NewDriver(CreateCeramicResolver(ceramic)))
+	defer server.Close()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	keyDID, err := CreateDIDKey(publicKey)
+	assert.NoError(t, err)
+
+	createResp, err := ceramic.CreateStream(api.CreateStreamRequest{Genesis: map[string]interface{}{
+		"header": map[string]interface{}{"controllers": []string{*keyDID}, "family": "3id"},
+		"data":   map[string]interface{}{"publicKeys": map[string]string{"signing": (*keyDID)[len(DIDPrefix)+1:]}},
+	}})
+	assert.NoError(t, err)
+	threeID := ThreeIDPrefix + ":" + createResp.Response.ID
+
+	get := func(tt *testing.T, serverURL, did, accept string) (*http.Response, map[string]interface{}) {
+		req, err := http.NewRequest(http.MethodGet, serverURL+IdentifiersPath+url.PathEscape(did), nil)
+		assert.NoError(tt, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(tt, err)
+		defer resp.Body.Close()
+		body := make(map[string]interface{})
+		assert.NoError(tt, json.NewDecoder(resp.Body).Decode(&body))
+		return resp, body
+	}
+
+	t.Run("resolution result of did:key", func(tt *testing.T) {
+		resp, body := get(tt, server.URL, *keyDID, "")
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, ResolutionResultContentType, resp.Header.Get("Content-Type"))
+		assert.Equal(tt, "https://w3id.org/did-resolution/v1", body["@context"])
+		assert.Equal(tt, *keyDID, body["didDocument"].(map[string]interface{})["id"])
+		assert.Equal(tt, DIDLDJSONContentType, body["didResolutionMetadata"].(map[string]interface{})["contentType"])
+	})
+
+	t.Run("resolution result of did:3", func(tt *testing.T) {
+		resp, body := get(tt, server.URL, threeID, `application/ld+json;profile="https://w3id.org/did-resolution"`)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, threeID, body["didDocument"].(map[string]interface{})["id"])
+		assert.NotEmpty(tt, body["didDocumentMetadata"].(map[string]interface{})["versionId"])
+	})
+
+	t.Run("document only", func(tt *testing.T) {
+		resp, body := get(tt, server.URL, *keyDID, DIDLDJSONContentType)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, DIDLDJSONContentType, resp.Header.Get("Content-Type"))
+		assert.Equal(tt, *keyDID, body["id"])
+		assert.NotNil(tt, body["@context"])
+
+		resp, body = get(tt, server.URL, *keyDID, "text/html, "+DIDJSONContentType)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, DIDJSONContentType, resp.Header.Get("Content-Type"))
+		assert.Equal(tt, *keyDID, body["id"])
+		assert.Nil(tt, body["@context"])
+	})
+
+	t.Run("q values", func(tt *testing.T) {
+		resp, _ := get(tt, server.URL, *keyDID, `application/did+json;q=0.1, application/ld+json;profile="https://w3id.org/did-resolution"`)
+		assert.Equal(tt, http.StatusOK, resp.StatusCode)
+		assert.Equal(tt, ResolutionResultContentType, resp.Header.Get("Content-Type"))
+
+		resp, _ = get(tt, server.URL, *keyDID, "application/json;q=0.5, "+DIDLDJSONContentType+";q=0.9")
+		assert.Equal(tt, DIDLDJSONContentType, resp.Header.Get("Content-Type"))
+
+		resp, _ = get(tt, server.URL, *keyDID, DIDJSONContentType+";q=0.0")
+		assert.Equal(tt, http.StatusNotAcceptable, resp.StatusCode)
+	})
+
+	t.Run("errors", func(tt *testing.T) {
+		resp, body := get(tt, server.URL, "not a did", "")
+		assert.Equal(tt, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(tt, InvalidDIDError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		resp, body = get(tt, server.URL, "did:key:bad", "")
+		assert.Equal(tt, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(tt, InvalidDIDError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		failing := httptest.NewServer(NewDriver(CreateDIDResolver("", failingMethod{})))
+		defer failing.Close()
+		resp, body = get(tt, failing.URL, "did:fail:123", "")
+		assert.Equal(tt, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(tt, InternalError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		resp, body = get(tt, server.URL, "did:example:123", "")
+		assert.Equal(tt, http.StatusNotImplemented, resp.StatusCode)
+		assert.Equal(tt, MethodNotSupportedError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		resp, body = get(tt, server.URL, *keyDID, "text/html")
+		assert.Equal(tt, http.StatusNotAcceptable, resp.StatusCode)
+		assert.Equal(tt, RepresentationNotSupportedError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		notFound := httptest.NewServer(NewDriver(stubResolver{err: &ResolutionError{Code: NotFoundError, Err: errors.New("not found")}}))
+		defer notFound.Close()
+		resp, body = get(tt, notFound.URL, threeID, "")
+		assert.Equal(tt, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(tt, NotFoundError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+
+		internal := httptest.NewServer(NewDriver(stubResolver{err: errors.New("boom")}))
+		defer internal.Close()
+		resp, body = get(tt, internal.URL, threeID, "")
+		assert.Equal(tt, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(tt, InternalError, body["didResolutionMetadata"].(map[string]interface{})["error"])
+	})
+}
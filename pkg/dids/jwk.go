@@ -0,0 +1,210 @@
+package dids
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	parse "github.com/ockam-network/did"
+	"github.com/textileio/go-did-resolver/resolver"
+	"math/big"
+	"strings"
+)
+
+// https://github.com/quartzjer/did-jwk/blob/main/spec.md
+
+const (
+	JWKPrefix      = "did:jwk"
+	JSONWebKey2020 = "JsonWebKey2020"
+
+	OKPKeyType = "OKP"
+	ECKeyType  = "EC"
+)
+
+// JWK is a public JSON Web Key. Its members are declared in lexicographic order, so a did:jwk created from it has the
+// same encoding other implementations produce.
+type JWK struct {
+	Curve   string `json:"crv"`
+	D       string `json:"d,omitempty"`
+	KeyType string `json:"kty"`
+	Use     string `json:"use,omitempty"`
+	X       string `json:"x"`
+	Y       string `json:"y,omitempty"`
+}
+
+// PublicKeyJWK creates the JWK of an Ed25519, secp256k1, P-256 or P-384 public key.
+func PublicKeyJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key length<%d>", len(key))
+		}
+		return &JWK{Curve: string(Ed25519KeyType), KeyType: OKPKeyType, X: base64.RawURLEncoding.EncodeToString(key)}, nil
+	case *btcec.PublicKey:
+		uncompressed := key.SerializeUncompressed()
+		return &JWK{
+			Curve:   string(Secp256k1KeyType),
+			KeyType: ECKeyType,
+			X:       base64.RawURLEncoding.EncodeToString(uncompressed[1:33]),
+			Y:       base64.RawURLEncoding.EncodeToString(uncompressed[33:]),
+		}, nil
+	case *ecdsa.PublicKey:
+		if _, err := curveMultiCodec(key.Curve); err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return &JWK{
+			Curve:   key.Curve.Params().Name,
+			KeyType: ECKeyType,
+			X:       base64.RawURLEncoding.EncodeToString(x),
+			Y:       base64.RawURLEncoding.EncodeToString(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type<%T>", publicKey)
+	}
+}
+
+// PublicKey returns the key the JWK describes.
+func (j JWK) PublicKey() (KeyType, crypto.PublicKey, error) {
+	if j.D != "" {
+		return "", nil, fmt.Errorf("jwk is a private key")
+	}
+	x, err := base64.RawURLEncoding.DecodeString(j.X)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid jwk x: %w", err)
+	}
+	switch {
+	case j.KeyType == OKPKeyType && j.Curve == string(Ed25519KeyType):
+		if len(x) != ed25519.PublicKeySize {
+			return "", nil, fmt.Errorf("invalid jwk: wrong ed25519 key length<%d>", len(x))
+		}
+		return Ed25519KeyType, ed25519.PublicKey(x), nil
+	case j.KeyType == ECKeyType:
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid jwk y: %w", err)
+		}
+		switch KeyType(j.Curve) {
+		case Secp256k1KeyType:
+			if len(x) != 32 || len(y) != 32 {
+				return "", nil, fmt.Errorf("invalid jwk: wrong secp256k1 coordinate length")
+			}
+			key, err := btcec.ParsePubKey(append(append([]byte{0x04}, x...), y...))
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid jwk: %w", err)
+			}
+			return Secp256k1KeyType, key, nil
+		case P256KeyType, P384KeyType:
+			curve := elliptic.P256()
+			if KeyType(j.Curve) == P384KeyType {
+				curve = elliptic.P384()
+			}
+			size := (curve.Params().BitSize + 7) / 8
+			if len(x) != size || len(y) != size {
+				return "", nil, fmt.Errorf("invalid jwk: wrong %s coordinate length", j.Curve)
+			}
+			key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			if !curve.IsOnCurve(key.X, key.Y) {
+				return "", nil, fmt.Errorf("invalid jwk: point is not on curve<%s>", j.Curve)
+			}
+			return KeyType(j.Curve), key, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unsupported jwk<%s/%s>", j.KeyType, j.Curve)
+}
+
+// CreateJWKDID creates the did:jwk of an Ed25519, secp256k1, P-256 or P-384 public key.
+func CreateJWKDID(publicKey crypto.PublicKey) (*string, error) {
+	jwk, err := PublicKeyJWK(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	jwkBytes, err := json.Marshal(jwk)
+	if err != nil {
+		return nil, err
+	}
+	did := fmt.Sprintf("%s:%s", JWKPrefix, base64.RawURLEncoding.EncodeToString(jwkBytes))
+	return &did, nil
+}
+
+// ParseJWKDID returns the JWK of a did:jwk and the key it describes.
+func ParseJWKDID(did string) (*JWK, KeyType, crypto.PublicKey, error) {
+	if !strings.HasPrefix(did, JWKPrefix+":") {
+		return nil, "", nil, fmt.Errorf("did<%s> is not a did:jwk", did)
+	}
+	jwkBytes, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(did, JWKPrefix+":"))
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid did:jwk<%s>: %w", did, err)
+	}
+	var jwk JWK
+	if err := json.Unmarshal(jwkBytes, &jwk); err != nil {
+		return nil, "", nil, fmt.Errorf("invalid did:jwk<%s>: %w", did, err)
+	}
+	keyType, publicKey, err := jwk.PublicKey()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("invalid did:jwk<%s>: %w", did, err)
+	}
+	return &jwk, keyType, publicKey, nil
+}
+
+// JWKResolver resolves did:jwk DIDs to the document the did:jwk spec derives: a single JsonWebKey2020 method, #0,
+// carrying the JWK, and referenced by the verification relationships the JWK's use allows.
+type JWKResolver struct{}
+
+func NewJWKResolver() *JWKResolver {
+	return &JWKResolver{}
+}
+
+func (r *JWKResolver) Method() string {
+	return "jwk"
+}
+
+func (r *JWKResolver) Resolve(did string, parsed *parse.DID, _ resolver.Resolver) (*resolver.Document, error) {
+	document, err := r.ResolveDocument(did, parsed)
+	if err != nil {
+		return nil, err
+	}
+	return toResolverDocument(*document), nil
+}
+
+func (r *JWKResolver) ResolveDocument(did string, parsed *parse.DID) (*Document, error) {
+	if parsed.Method != r.Method() {
+		return nil, fmt.Errorf("unknown did method: '%s'", parsed.Method)
+	}
+	did = fmt.Sprintf("%s:%s", JWKPrefix, parsed.ID)
+	jwk, _, _, err := ParseJWKDID(did)
+	if err != nil {
+		return nil, resolutionError(InvalidDIDError, err)
+	}
+
+	document := Document{
+		Context: []string{"https://www.w3.org/ns/did/v1", "https://w3id.org/security/suites/jws-2020/v1"},
+		ID:      did,
+		VerificationMethod: []VerificationMethod{{
+			ID:           did + "#0",
+			Type:         JSONWebKey2020,
+			Controller:   did,
+			PublicKeyJwk: jwk,
+		}},
+	}
+	reference := []VerificationMethod{{ID: did + "#0"}}
+	if jwk.Use != "enc" {
+		document.AssertionMethod = reference
+		document.Authentication = reference
+		document.CapabilityInvocation = reference
+		document.CapabilityDelegation = reference
+	}
+	if jwk.Use != "sig" {
+		document.KeyAgreement = reference
+	}
+	return &document, nil
+}
+
+var _ DocumentResolver = (*JWKResolver)(nil)
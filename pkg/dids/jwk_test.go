@@ -0,0 +1,129 @@
+package dids
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJWK(t *testing.T) {
+	t.Run("spec example", func(tt *testing.T) {
+		// https://github.com/quartzjer/did-jwk/blob/main/spec.md#p-256
+		did := "did:jwk:eyJjcnYiOiJQLTI1NiIsImt0eSI6IkVDIiwieCI6ImFjYklRaXVNczNpOF91c3pFakoydHBUdFJNNEVVM3l6OTFQSDZDZEgyVjAiLCJ5IjoiX0tjeUxqOXZXTXB0bm1LdG00NkdxRHo4d2Y3NEk1TEtncmwyR3pIM25TRSJ9"
+		jwk, keyType, publicKey, err := ParseJWKDID(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, P256KeyType, keyType)
+		assert.Equal(tt, "acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0", jwk.X)
+
+		created, err := CreateJWKDID(publicKey)
+		assert.NoError(tt, err)
+		assert.Equal(tt, did, *created)
+	})
+
+	t.Run("round trip", func(tt *testing.T) {
+		edKey, _, err := internal.GenerateEd25519Key()
+		assert.NoError(tt, err)
+		secpKey, _, err := internal.GenerateSecp256k1Key()
+		assert.NoError(tt, err)
+		p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(tt, err)
+
+		for keyType, publicKey := range map[KeyType]interface{}{
+			Ed25519KeyType:   edKey,
+			Secp256k1KeyType: secpKey,
+			P256KeyType:      &p256Key.PublicKey,
+		} {
+			did, err := CreateJWKDID(publicKey)
+			assert.NoError(tt, err)
+			_, parsedType, parsedKey, err := ParseJWKDID(*did)
+			assert.NoError(tt, err)
+			assert.Equal(tt, keyType, parsedType)
+			assert.Equal(tt, publicKey, parsedKey)
+		}
+	})
+
+	t.Run("resolve spec example", func(tt *testing.T) {
+		// https://github.com/quartzjer/did-jwk/blob/main/spec.md#p-256
+		did := "did:jwk:eyJjcnYiOiJQLTI1NiIsImt0eSI6IkVDIiwieCI6ImFjYklRaXVNczNpOF91c3pFakoydHBUdFJNNEVVM3l6OTFQSDZDZEgyVjAiLCJ5IjoiX0tjeUxqOXZXTXB0bm1LdG00NkdxRHo4d2Y3NEk1TEtncmwyR3pIM25TRSJ9"
+		resolved, err := CreateDefaultResolver("").Resolve(did)
+		assert.NoError(tt, err)
+		documentJSON, err := json.Marshal(resolved.Document)
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `{
+			"@context": ["https://www.w3.org/ns/did/v1", "https://w3id.org/security/suites/jws-2020/v1"],
+			"id": "`+did+`",
+			"verificationMethod": [{
+				"id": "`+did+`#0",
+				"type": "JsonWebKey2020",
+				"controller": "`+did+`",
+				"publicKeyJwk": {
+					"crv": "P-256",
+					"kty": "EC",
+					"x": "acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0",
+					"y": "_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE"
+				}
+			}],
+			"assertionMethod": ["`+did+`#0"],
+			"authentication": ["`+did+`#0"],
+			"capabilityInvocation": ["`+did+`#0"],
+			"capabilityDelegation": ["`+did+`#0"],
+			"keyAgreement": ["`+did+`#0"]
+		}`, string(documentJSON))
+
+		var decoded Document
+		assert.NoError(tt, json.Unmarshal(documentJSON, &decoded))
+		assert.Equal(tt, resolved.Document, decoded)
+	})
+
+	t.Run("resolve by use", func(tt *testing.T) {
+		encode := func(jwk string) string {
+			return JWKPrefix + ":" + base64.RawURLEncoding.EncodeToString([]byte(jwk))
+		}
+		resolver := CreateDIDResolver("", NewJWKResolver())
+		signing, err := resolver.Resolve(encode(`{"crv":"Ed25519","kty":"OKP","use":"sig","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		assert.NoError(tt, err)
+		assert.Len(tt, signing.Authentication, 1)
+		assert.Empty(tt, signing.KeyAgreement)
+
+		encryption, err := resolver.Resolve(encode(`{"crv":"P-256","kty":"EC","use":"enc","x":"acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0","y":"_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE"}`))
+		assert.NoError(tt, err)
+		assert.Empty(tt, encryption.Authentication)
+		assert.Len(tt, encryption.KeyAgreement, 1)
+	})
+
+	t.Run("coordinates must be the field size", func(tt *testing.T) {
+		x, err := base64.RawURLEncoding.DecodeString("acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0")
+		assert.NoError(tt, err)
+		padded := JWK{
+			KeyType: ECKeyType,
+			Curve:   string(P256KeyType),
+			X:       base64.RawURLEncoding.EncodeToString(append([]byte{0}, x...)),
+			Y:       "_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE",
+		}
+		_, _, err = padded.PublicKey()
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "coordinate length")
+	})
+
+	t.Run("invalid", func(tt *testing.T) {
+		encode := func(jwk string) string {
+			return JWKPrefix + ":" + base64.RawURLEncoding.EncodeToString([]byte(jwk))
+		}
+		for _, did := range []string{
+			"did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H",
+			"did:jwk:not-base64!",
+			encode(`{"kty":"OKP","crv":"X25519","x":"3p7bfXt9wbTTW2HC7OQ1Nz-DQ8hbeGdNrfx-FG-IK08"}`),
+			encode(`{"kty":"OKP","crv":"Ed25519","x":"AAAA"}`),
+			encode(`{"kty":"EC","crv":"P-256","x":"AAAA","y":"AAAA"}`),
+			encode(`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`),
+		} {
+			_, _, _, err := ParseJWKDID(did)
+			assert.Error(tt, err, did)
+		}
+	})
+}
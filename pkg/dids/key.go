@@ -1,11 +1,17 @@
 package dids
 
 import (
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
 	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-varint"
+	"strings"
 )
 
 const (
@@ -15,13 +21,123 @@ const (
 	// Ed25519MultiCodec ed25519-pub https://github.com/multiformats/multicodec/blob/master/table.csv
 	Ed25519MultiCodec = multicodec.Ed25519Pub
 
+	// Secp256k1MultiCodec secp256k1-pub https://github.com/multiformats/multicodec/blob/master/table.csv
+	Secp256k1MultiCodec = multicodec.Secp256k1Pub
+
+	// P256MultiCodec p256-pub https://github.com/multiformats/multicodec/blob/master/table.csv
+	P256MultiCodec = multicodec.P256Pub
+
+	// P384MultiCodec p384-pub https://github.com/multiformats/multicodec/blob/master/table.csv
+	P384MultiCodec = multicodec.P384Pub
+
 	// DIDPrefix did:key prefix
 	DIDPrefix = "did:key"
 )
 
+type KeyType string
+
+const (
+	Ed25519KeyType   KeyType = "Ed25519"
+	Secp256k1KeyType KeyType = "secp256k1"
+	P256KeyType      KeyType = "P-256"
+	P384KeyType      KeyType = "P-384"
+)
+
 func CreateDIDKey(key ed25519.PublicKey) (*string, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key length<%d>", len(key))
+	}
+	return createDIDKey(Ed25519MultiCodec, key)
+}
+
+// CreateSecp256k1DIDKey creates the did:key of a secp256k1 public key, which is identified by its compressed form.
+func CreateSecp256k1DIDKey(key *btcec.PublicKey) (*string, error) {
+	if key == nil {
+		return nil, errors.New("missing secp256k1 public key")
+	}
+	return createDIDKey(Secp256k1MultiCodec, key.SerializeCompressed())
+}
+
+// ParseSecp256k1DIDKey returns the public key of a secp256k1 did:key.
+func ParseSecp256k1DIDKey(did string) (*btcec.PublicKey, error) {
+	codec, keyBytes, err := decodeDIDKey(did)
+	if err != nil {
+		return nil, err
+	}
+	if codec != Secp256k1MultiCodec {
+		return nil, fmt.Errorf("did<%s> is not a secp256k1 key", did)
+	}
+	return parseSecp256k1Key(did, keyBytes)
+}
+
+// CreateECDSADIDKey creates the did:key of a P-256 or P-384 public key, which is identified by its compressed form.
+func CreateECDSADIDKey(key *ecdsa.PublicKey) (*string, error) {
+	if key == nil {
+		return nil, errors.New("missing ecdsa public key")
+	}
+	codec, err := curveMultiCodec(key.Curve)
+	if err != nil {
+		return nil, err
+	}
+	return createDIDKey(codec, elliptic.MarshalCompressed(key.Curve, key.X, key.Y))
+}
+
+// ParseECDSADIDKey returns the public key of a P-256 or P-384 did:key.
+func ParseECDSADIDKey(did string) (*ecdsa.PublicKey, error) {
+	codec, keyBytes, err := decodeDIDKey(did)
+	if err != nil {
+		return nil, err
+	}
+	return parseECDSAKey(did, codec, keyBytes)
+}
+
+func curveMultiCodec(curve elliptic.Curve) (multicodec.Code, error) {
+	switch curve {
+	case elliptic.P256():
+		return P256MultiCodec, nil
+	case elliptic.P384():
+		return P384MultiCodec, nil
+	default:
+		return 0, fmt.Errorf("unsupported curve<%s>", curve.Params().Name)
+	}
+}
+
+// ParseDIDKey decodes a did:key into its key type and public key. The public key is an ed25519.PublicKey, a
+// *btcec.PublicKey or an *ecdsa.PublicKey depending on the key type.
+func ParseDIDKey(did string) (KeyType, crypto.PublicKey, error) {
+	codec, keyBytes, err := decodeDIDKey(did)
+	if err != nil {
+		return "", nil, err
+	}
+	switch codec {
+	case Ed25519MultiCodec:
+		if len(keyBytes) != ed25519.PublicKeySize {
+			return "", nil, fmt.Errorf("invalid did:key<%s>: wrong ed25519 key length<%d>", did, len(keyBytes))
+		}
+		return Ed25519KeyType, ed25519.PublicKey(keyBytes), nil
+	case Secp256k1MultiCodec:
+		key, err := parseSecp256k1Key(did, keyBytes)
+		if err != nil {
+			return "", nil, err
+		}
+		return Secp256k1KeyType, key, nil
+	case P256MultiCodec, P384MultiCodec:
+		key, err := parseECDSAKey(did, codec, keyBytes)
+		if err != nil {
+			return "", nil, err
+		}
+		if codec == P384MultiCodec {
+			return P384KeyType, key, nil
+		}
+		return P256KeyType, key, nil
+	default:
+		return "", nil, fmt.Errorf("invalid did:key<%s>: unsupported key type<%s>", did, codec)
+	}
+}
+
+func createDIDKey(codec multicodec.Code, key []byte) (*string, error) {
 	// did:key:<multibase encoded, multicodec identified, public key>
-	prefix := varint.ToUvarint(uint64(Ed25519MultiCodec))
+	prefix := varint.ToUvarint(uint64(codec))
 	multiCodec := append(prefix, key...)
 	encoded, err := multibase.Encode(Base58BTCMultiBase, multiCodec)
 	if err != nil {
@@ -30,3 +146,54 @@ func CreateDIDKey(key ed25519.PublicKey) (*string, error) {
 	did := fmt.Sprintf("%s:%s", DIDPrefix, encoded)
 	return &did, nil
 }
+
+// decodeDIDKey splits a did:key into its multicodec key type and public key bytes.
+func decodeDIDKey(did string) (multicodec.Code, []byte, error) {
+	if !strings.HasPrefix(did, DIDPrefix+":") {
+		return 0, nil, fmt.Errorf("did<%s> is not a did:key", did)
+	}
+	fingerprint := strings.TrimPrefix(did, DIDPrefix+":")
+	encoding, keyBytes, err := multibase.Decode(fingerprint)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid did:key<%s>: %w", did, err)
+	}
+	codec, n, err := varint.FromUvarint(keyBytes)
+	if err != nil || n != 2 {
+		return 0, nil, fmt.Errorf("invalid did:key<%s>: error parsing varint", did)
+	}
+	if encoding != Base58BTCMultiBase {
+		return 0, nil, fmt.Errorf("invalid did:key<%s>: not base58btc encoded", did)
+	}
+	return multicodec.Code(codec), keyBytes[n:], nil
+}
+
+func parseSecp256k1Key(did string, keyBytes []byte) (*btcec.PublicKey, error) {
+	if len(keyBytes) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("invalid did:key<%s>: wrong secp256k1 key length<%d>", did, len(keyBytes))
+	}
+	key, err := btcec.ParsePubKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid did:key<%s>: %w", did, err)
+	}
+	return key, nil
+}
+
+func parseECDSAKey(did string, codec multicodec.Code, keyBytes []byte) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch codec {
+	case P256MultiCodec:
+		curve = elliptic.P256()
+	case P384MultiCodec:
+		curve = elliptic.P384()
+	default:
+		return nil, fmt.Errorf("did<%s> is not a P-256 or P-384 key", did)
+	}
+	if len(keyBytes) != 1+(curve.Params().BitSize+7)/8 {
+		return nil, fmt.Errorf("invalid did:key<%s>: wrong %s key length<%d>", did, curve.Params().Name, len(keyBytes))
+	}
+	x, y := elliptic.UnmarshalCompressed(curve, keyBytes)
+	if x == nil {
+		return nil, fmt.Errorf("invalid did:key<%s>: not a compressed %s point", did, curve.Params().Name)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
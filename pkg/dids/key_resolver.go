@@ -0,0 +1,79 @@
+package dids
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/multiformats/go-multibase"
+	parse "github.com/ockam-network/did"
+	"github.com/textileio/go-did-resolver/keys"
+	"github.com/textileio/go-did-resolver/resolver"
+)
+
+// https://w3c-ccg.github.io/did-method-key/
+
+const (
+	Secp256k1VerificationKey2019 = "EcdsaSecp256k1VerificationKey2019"
+	P256Key2021                  = "P256Key2021"
+	P384Key2021                  = "P384Key2021"
+)
+
+// KeyResolver resolves did:key DIDs of every key type this package supports.
+type KeyResolver struct{}
+
+func NewKeyResolver() *KeyResolver {
+	return &KeyResolver{}
+}
+
+func (r *KeyResolver) Method() string {
+	return "key"
+}
+
+func (r *KeyResolver) Resolve(did string, parsed *parse.DID, _ resolver.Resolver) (*resolver.Document, error) {
+	if parsed.Method != r.Method() {
+		return nil, fmt.Errorf("unknown did method: '%s'", parsed.Method)
+	}
+	did = fmt.Sprintf("%s:%s", DIDPrefix, parsed.ID)
+	keyType, publicKey, err := ParseDIDKey(did)
+	if err != nil {
+		return nil, resolutionError(InvalidDIDError, err)
+	}
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return keys.ExpandEd25519Key(key, parsed.ID)
+	case *btcec.PublicKey:
+		return expandKey(did, parsed.ID, Secp256k1VerificationKey2019, key.SerializeCompressed())
+	case *ecdsa.PublicKey:
+		switch keyType {
+		case P256KeyType:
+			return expandKey(did, parsed.ID, P256Key2021, elliptic.MarshalCompressed(key.Curve, key.X, key.Y))
+		case P384KeyType:
+			return expandKey(did, parsed.ID, P384Key2021, elliptic.MarshalCompressed(key.Curve, key.X, key.Y))
+		}
+	}
+	return nil, fmt.Errorf("unsupported did:key<%s> of key type<%s>", did, keyType)
+}
+
+// expandKey creates the document of a did:key with a single key, usable for authentication.
+func expandKey(did, fingerprint, methodType string, keyBytes []byte) (*resolver.Document, error) {
+	keyMultibase, err := multibase.Encode(Base58BTCMultiBase, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	method := resolver.VerificationMethod{
+		ID:                 fmt.Sprintf("%s#%s", did, fingerprint),
+		Type:               methodType,
+		Controller:         did,
+		PublicKeyMultibase: keyMultibase,
+	}
+	return &resolver.Document{
+		Context:            []string{"https://w3id.org/did/v1"},
+		ID:                 did,
+		VerificationMethod: []resolver.VerificationMethod{method},
+		Authentication:     []resolver.VerificationMethod{method},
+	}, nil
+}
+
+var _ resolver.Resolver = (*KeyResolver)(nil)
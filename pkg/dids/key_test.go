@@ -1,10 +1,19 @@
 package dids
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
 	"github.com/ockam-network/did"
 	"github.com/stretchr/testify/assert"
 	"github.com/textileio/go-did-resolver/keys"
+	"strings"
 	"testing"
 )
 
@@ -31,3 +40,149 @@ func TestCreateDIDKey(t *testing.T) {
 	assert.Equal(t, "X25519KeyAgreementKey2019", document.VerificationMethod[0].Type)
 	assert.Equal(t, *didKey, document.VerificationMethod[0].Controller)
 }
+
+func TestCreateSecp256k1DIDKey(t *testing.T) {
+	pk, sk, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, sk)
+
+	didKey, err := CreateSecp256k1DIDKey(pk)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(*didKey, "did:key:zQ3s"))
+
+	parsed, err := ParseSecp256k1DIDKey(*didKey)
+	assert.NoError(t, err)
+	assert.True(t, pk.IsEqual(parsed))
+
+	_, err = ParseSecp256k1DIDKey("did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8")
+	assert.Error(t, err)
+	_, err = ParseSecp256k1DIDKey("did:web:example.com")
+	assert.Error(t, err)
+
+	_, err = CreateSecp256k1DIDKey(nil)
+	assert.Error(t, err)
+
+	t.Run("resolve", func(tt *testing.T) {
+		resolvedDID, err := CreateDIDResolver("", NewKeyResolver()).Resolve(*didKey)
+		assert.NoError(tt, err)
+		assert.Equal(tt, *didKey, resolvedDID.Document.ID)
+		assert.Len(tt, resolvedDID.Document.VerificationMethod, 1)
+		method := resolvedDID.Document.VerificationMethod[0]
+		assert.Equal(tt, Secp256k1VerificationKey2019, method.Type)
+		assert.Equal(tt, *didKey+"#"+strings.TrimPrefix(*didKey, "did:key:"), method.ID)
+		assert.Equal(tt, []VerificationMethod{method}, resolvedDID.Document.Authentication)
+	})
+
+	t.Run("known secp256k1 did:key", func(tt *testing.T) {
+		// https://w3c-ccg.github.io/did-method-key/#secp256k1
+		did := "did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme"
+		parsed, err := ParseSecp256k1DIDKey(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, "03874c15c7fda20e539c6e5ba573c139884c351188799f5458b4b41f7924f235cd", hex.EncodeToString(parsed.SerializeCompressed()))
+	})
+}
+
+func TestCreateECDSADIDKey(t *testing.T) {
+	tests := []struct {
+		curve      elliptic.Curve
+		prefix     string
+		methodType string
+	}{
+		{elliptic.P256(), "did:key:zDn", P256Key2021},
+		{elliptic.P384(), "did:key:z82", P384Key2021},
+	}
+	for _, test := range tests {
+		t.Run(test.curve.Params().Name, func(tt *testing.T) {
+			sk, err := ecdsa.GenerateKey(test.curve, rand.Reader)
+			assert.NoError(tt, err)
+
+			didKey, err := CreateECDSADIDKey(&sk.PublicKey)
+			assert.NoError(tt, err)
+			assert.True(tt, strings.HasPrefix(*didKey, test.prefix))
+
+			parsed, err := ParseECDSADIDKey(*didKey)
+			assert.NoError(tt, err)
+			assert.True(tt, sk.PublicKey.Equal(parsed))
+
+			resolvedDID, err := CreateDIDResolver("", NewKeyResolver()).Resolve(*didKey)
+			assert.NoError(tt, err)
+			assert.Equal(tt, test.methodType, resolvedDID.Document.VerificationMethod[0].Type)
+		})
+	}
+
+	sk, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	assert.NoError(t, err)
+	_, err = CreateECDSADIDKey(&sk.PublicKey)
+	assert.Error(t, err)
+
+	t.Run("known P-256 did:key", func(tt *testing.T) {
+		// https://w3c-ccg.github.io/did-method-key/#p-256
+		key, err := ParseECDSADIDKey("did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169")
+		assert.NoError(tt, err)
+		assert.Equal(tt, elliptic.P256(), key.Curve)
+	})
+
+	_, err = ParseECDSADIDKey("did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8")
+	assert.Error(t, err)
+}
+
+func TestParseDIDKey(t *testing.T) {
+	edKey, _, err := internal.GenerateEd25519Key()
+	assert.NoError(t, err)
+	edDID, err := CreateDIDKey(edKey)
+	assert.NoError(t, err)
+	secpKey, _, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	secpDID, err := CreateSecp256k1DIDKey(secpKey)
+	assert.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	p256DID, err := CreateECDSADIDKey(&p256Key.PublicKey)
+	assert.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	p384DID, err := CreateECDSADIDKey(&p384Key.PublicKey)
+	assert.NoError(t, err)
+
+	t.Run("supported key types", func(tt *testing.T) {
+		tests := []struct {
+			did     string
+			keyType KeyType
+			key     crypto.PublicKey
+		}{
+			{*edDID, Ed25519KeyType, edKey},
+			{*secpDID, Secp256k1KeyType, secpKey},
+			{*p256DID, P256KeyType, &p256Key.PublicKey},
+			{*p384DID, P384KeyType, &p384Key.PublicKey},
+		}
+		for _, test := range tests {
+			keyType, key, err := ParseDIDKey(test.did)
+			assert.NoError(tt, err)
+			assert.Equal(tt, test.keyType, keyType)
+			assert.Equal(tt, test.key, key)
+		}
+	})
+
+	encode := func(encoding multibase.Encoding, codec multicodec.Code, key []byte) string {
+		encoded, err := multibase.Encode(encoding, append(varint.ToUvarint(uint64(codec)), key...))
+		assert.NoError(t, err)
+		return "did:key:" + encoded
+	}
+
+	t.Run("invalid keys", func(tt *testing.T) {
+		invalid := map[string]string{
+			"not a did:key":      "did:web:example.com",
+			"not multibase":      "did:key:!abc",
+			"not base58btc":      encode(multibase.Base16, Ed25519MultiCodec, edKey),
+			"bad varint":         "did:key:z1",
+			"unsupported codec":  encode(multibase.Base58BTC, multicodec.X25519Pub, edKey),
+			"short ed25519 key":  encode(multibase.Base58BTC, Ed25519MultiCodec, edKey[:31]),
+			"uncompressed point": encode(multibase.Base58BTC, P256MultiCodec, elliptic.Marshal(elliptic.P256(), p256Key.X, p256Key.Y)),
+			"not on the curve":   encode(multibase.Base58BTC, Secp256k1MultiCodec, append([]byte{2}, make([]byte, 32)...)),
+		}
+		for name, did := range invalid {
+			_, _, err := ParseDIDKey(did)
+			assert.Error(tt, err, name)
+		}
+	})
+}
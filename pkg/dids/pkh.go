@@ -0,0 +1,121 @@
+package dids
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	parse "github.com/ockam-network/did"
+	"github.com/textileio/go-did-resolver/resolver"
+	"golang.org/x/crypto/sha3"
+	"strings"
+)
+
+// https://github.com/w3c-ccg/did-pkh/blob/main/did-pkh-method-draft.md
+
+const (
+	PKHPrefix                        = "did:pkh"
+	EIP155Namespace                  = "eip155"
+	EcdsaSecp256k1RecoveryMethod2020 = "EcdsaSecp256k1RecoveryMethod2020"
+)
+
+// CreatePKHDID creates the did:pkh of an Ethereum account on the given eip155 chain.
+func CreatePKHDID(chainID, address string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", PKHPrefix, EIP155Namespace, chainID, address)
+}
+
+// ParsePKHDID returns the chain and account address of an eip155 did:pkh.
+func ParsePKHDID(did string) (chainID, address string, err error) {
+	parts := strings.Split(did, ":")
+	if len(parts) != 5 || strings.Join(parts[:2], ":") != PKHPrefix {
+		return "", "", fmt.Errorf("did<%s> is not a did:pkh", did)
+	}
+	if parts[2] != EIP155Namespace {
+		return "", "", fmt.Errorf("did:pkh<%s> has unsupported namespace<%s>", did, parts[2])
+	}
+	chainID, address = parts[3], parts[4]
+	if chainID == "" || strings.Trim(chainID, "0123456789") != "" {
+		return "", "", fmt.Errorf("did:pkh<%s> has invalid chain id<%s>", did, chainID)
+	}
+	if !isEthereumAddress(address) {
+		return "", "", fmt.Errorf("did:pkh<%s> has invalid address<%s>", did, address)
+	}
+	return chainID, address, nil
+}
+
+// EthereumAddress returns the EIP-55 checksummed address of a secp256k1 public key.
+func EthereumAddress(publicKey *btcec.PublicKey) string {
+	hash := keccak256(publicKey.SerializeUncompressed()[1:])
+	return checksumAddress(hex.EncodeToString(hash[12:]))
+}
+
+func isEthereumAddress(address string) bool {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// checksumAddress capitalizes the hex letters of an address whose nibble in the hash of the address is 8 or more.
+// https://eips.ethereum.org/EIPS/eip-55
+func checksumAddress(address string) string {
+	address = strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := hex.EncodeToString(keccak256([]byte(address)))
+	checksummed := []byte(address)
+	for i, c := range checksummed {
+		if c >= 'a' && hash[i] >= '8' {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed)
+}
+
+func keccak256(data []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// PKHResolver resolves eip155 did:pkh DIDs. The document's single method is the account itself, named by its CAIP-10
+// account ID; signatures by it are checked by recovering the signing address.
+type PKHResolver struct{}
+
+func NewPKHResolver() *PKHResolver {
+	return &PKHResolver{}
+}
+
+func (r *PKHResolver) Method() string {
+	return "pkh"
+}
+
+func (r *PKHResolver) Resolve(did string, parsed *parse.DID, _ resolver.Resolver) (*resolver.Document, error) {
+	document, err := r.ResolveDocument(did, parsed)
+	if err != nil {
+		return nil, err
+	}
+	return toResolverDocument(*document), nil
+}
+
+func (r *PKHResolver) ResolveDocument(did string, parsed *parse.DID) (*Document, error) {
+	if parsed.Method != r.Method() {
+		return nil, fmt.Errorf("unknown did method: '%s'", parsed.Method)
+	}
+	did = fmt.Sprintf("%s:%s", PKHPrefix, parsed.ID)
+	if _, _, err := ParsePKHDID(did); err != nil {
+		return nil, resolutionError(InvalidDIDError, err)
+	}
+	method := VerificationMethod{
+		ID:                  did + "#blockchainAccountId",
+		Type:                EcdsaSecp256k1RecoveryMethod2020,
+		Controller:          did,
+		BlockchainAccountID: parsed.ID,
+	}
+	return &Document{
+		Context:            []string{"https://w3id.org/did/v1", "https://w3id.org/security/suites/secp256k1recovery-2020/v2"},
+		ID:                 did,
+		VerificationMethod: []VerificationMethod{method},
+		Authentication:     []VerificationMethod{method},
+	}, nil
+}
+
+var _ DocumentResolver = (*PKHResolver)(nil)
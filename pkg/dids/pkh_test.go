@@ -0,0 +1,55 @@
+package dids
+
+import (
+	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestPKH(t *testing.T) {
+	t.Run("ethereum address", func(tt *testing.T) {
+		// https://eips.ethereum.org/EIPS/eip-155
+		keyBytes, err := hex.DecodeString(strings.Repeat("46", 32))
+		assert.NoError(tt, err)
+		privateKey, _ := btcec.PrivKeyFromBytes(keyBytes)
+		assert.Equal(tt, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", EthereumAddress(privateKey.PubKey()))
+
+		// https://eips.ethereum.org/EIPS/eip-55
+		assert.Equal(tt, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", checksumAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+	})
+
+	t.Run("parse", func(tt *testing.T) {
+		did := CreatePKHDID("1", "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F")
+		assert.Equal(tt, "did:pkh:eip155:1:0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", did)
+		chainID, address, err := ParsePKHDID(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, "1", chainID)
+		assert.Equal(tt, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", address)
+
+		for _, bad := range []string{
+			"did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H",
+			"did:pkh:bip122:000000000019d6689c085ae165831e93:128Lkh3S7CkDTBZ8W7BbpsN3YYizJMp8p6",
+			"did:pkh:eip155:one:0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F",
+			"did:pkh:eip155:1:0x9d8A62",
+		} {
+			_, _, err := ParsePKHDID(bad)
+			assert.Error(tt, err, bad)
+		}
+	})
+
+	t.Run("resolve", func(tt *testing.T) {
+		resolver := CreateDIDResolver("", NewPKHResolver())
+		did := "did:pkh:eip155:1:0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"
+		resolved, err := resolver.Resolve(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, did, resolved.ID)
+		assert.Equal(tt, did+"#blockchainAccountId", resolved.VerificationMethod[0].ID)
+		assert.Equal(tt, "eip155:1:0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", resolved.VerificationMethod[0].BlockchainAccountID)
+		assert.Equal(tt, EcdsaSecp256k1RecoveryMethod2020, resolved.Authentication[0].Type)
+
+		_, err = resolver.Resolve("did:pkh:eip155:1:bad")
+		assert.Error(tt, err)
+	})
+}
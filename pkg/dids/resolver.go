@@ -1,48 +1,307 @@
 package dids
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/textileio/go-did-resolver/keys"
+	parse "github.com/ockam-network/did"
 	"github.com/textileio/go-did-resolver/resolver"
 	"github.com/textileio/go-did-resolver/threeid"
+	"time"
 )
 
+// MetadataTimeFormat is the XML datetime format, normalized to UTC, of the times in DocumentMetadata.
+const MetadataTimeFormat = "2006-01-02T15:04:05Z"
+
+// Error codes of the DID resolution metadata.
+// https://www.w3.org/TR/did-spec-registries/#error
+const (
+	InvalidDIDError                 = "invalidDid"
+	NotFoundError                   = "notFound"
+	MethodNotSupportedError         = "methodNotSupported"
+	RepresentationNotSupportedError = "representationNotSupported"
+	InternalError                   = "internalError"
+)
+
+// ResolutionError is returned when a DID cannot be resolved. Code is the error of the DID resolution metadata.
+type ResolutionError struct {
+	Code string
+	Err  error
+}
+
+func (e *ResolutionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ResolutionError) Unwrap() error {
+	return e.Err
+}
+
+func resolutionError(code string, err error) error {
+	return &ResolutionError{Code: code, Err: err}
+}
+
 type ResolvedDID struct {
 	resolver.ResolutionMetadata
-	resolver.Document
+	Document
+	DocumentMetadata
+}
+
+// Document is a DID document. It follows resolver.Document, whose verification methods cannot hold the properties
+// some methods need, such as the CAIP-10 account of a did:pkh or the JWK of a did:jwk.
+// https://www.w3.org/TR/did-core/#did-documents
+type Document struct {
+	Context              []string                   `json:"@context"`
+	ID                   string                     `json:"id"`
+	Controller           []string                   `json:"controller,omitempty"`
+	VerificationMethod   []VerificationMethod       `json:"verificationMethod,omitempty"`
+	AssertionMethod      []VerificationMethod       `json:"assertionMethod,omitempty"`
+	Authentication       []VerificationMethod       `json:"authentication,omitempty"`
+	CapabilityInvocation []VerificationMethod       `json:"capabilityInvocation,omitempty"`
+	CapabilityDelegation []VerificationMethod       `json:"capabilityDelegation,omitempty"`
+	KeyAgreement         []VerificationMethod       `json:"keyAgreement,omitempty"`
+	Service              []resolver.ServiceEndpoint `json:"service,omitempty"`
+}
+
+// VerificationMethod is a verification method of a Document.
+// https://www.w3.org/TR/did-core/#verification-methods
+type VerificationMethod struct {
+	ID                  string `json:"id,omitempty"`
+	Type                string `json:"type,omitempty"`
+	Controller          string `json:"controller,omitempty"`
+	PublicKeyMultibase  string `json:"publicKeyMultibase,omitempty"`
+	PublicKey           string `json:"publicKey,omitempty"`
+	PublicKeyJwk        *JWK   `json:"publicKeyJwk,omitempty"`
+	BlockchainAccountID string `json:"blockchainAccountId,omitempty"`
+}
+
+// MarshalJSON writes a method with nothing but an ID as a reference to a method of the document, the form verification
+// relationships use to share a method.
+func (m VerificationMethod) MarshalJSON() ([]byte, error) {
+	if m == (VerificationMethod{ID: m.ID}) {
+		return json.Marshal(m.ID)
+	}
+	type method VerificationMethod
+	return json.Marshal(method(m))
+}
+
+func (m *VerificationMethod) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*m = VerificationMethod{ID: id}
+		return nil
+	}
+	type method VerificationMethod
+	return json.Unmarshal(data, (*method)(m))
+}
+
+func fromResolverDocument(document resolver.Document) Document {
+	return Document{
+		Context:            document.Context,
+		ID:                 document.ID,
+		Controller:         document.Controller,
+		VerificationMethod: fromResolverMethods(document.VerificationMethod),
+		Authentication:     fromResolverMethods(document.Authentication),
+		KeyAgreement:       fromResolverMethods(document.KeyAgreement),
+		Service:            document.Service,
+	}
+}
+
+func fromResolverMethods(methods []resolver.VerificationMethod) []VerificationMethod {
+	if methods == nil {
+		return nil
+	}
+	converted := make([]VerificationMethod, 0, len(methods))
+	for _, method := range methods {
+		converted = append(converted, VerificationMethod{
+			ID:                 method.ID,
+			Type:               method.Type,
+			Controller:         method.Controller,
+			PublicKeyMultibase: method.PublicKeyMultibase,
+			PublicKey:          method.PublicKey,
+		})
+	}
+	return converted
+}
+
+// toResolverDocument converts document for the resolver registry, dropping the properties resolver.Document lacks.
+func toResolverDocument(document Document) *resolver.Document {
+	return &resolver.Document{
+		Context:            document.Context,
+		ID:                 document.ID,
+		Controller:         document.Controller,
+		VerificationMethod: toResolverMethods(document.VerificationMethod),
+		Authentication:     toResolverMethods(document.Authentication),
+		KeyAgreement:       toResolverMethods(document.KeyAgreement),
+		Service:            document.Service,
+	}
+}
+
+func toResolverMethods(methods []VerificationMethod) []resolver.VerificationMethod {
+	if methods == nil {
+		return nil
+	}
+	converted := make([]resolver.VerificationMethod, 0, len(methods))
+	for _, method := range methods {
+		converted = append(converted, resolver.VerificationMethod{
+			ID:                 method.ID,
+			Type:               method.Type,
+			Controller:         method.Controller,
+			PublicKeyMultibase: method.PublicKeyMultibase,
+			PublicKey:          method.PublicKey,
+		})
+	}
+	return converted
+}
+
+// DocumentMetadata adds the properties describing the versions of a document around the resolved one.
+// https://www.w3.org/TR/did-core/#did-document-metadata
+type DocumentMetadata struct {
 	resolver.DocumentMetadata
+	NextUpdate    string `json:"nextUpdate,omitempty"`
+	NextVersionID string `json:"nextVersionId,omitempty"`
+}
+
+// ResolutionOptions select the version of a document to resolve. At most one of them may be set; without either the
+// latest version is resolved.
+type ResolutionOptions struct {
+	// VersionID is the version to resolve, for did:3 the CID of a commit of its 3ID tile.
+	VersionID string
+	// VersionTime resolves the version that was current at the given time.
+	VersionTime time.Time
+}
+
+func (o ResolutionOptions) isSet() bool {
+	return o.VersionID != "" || !o.VersionTime.IsZero()
+}
+
+// VersionedResolver is a method resolver that can resolve past versions of documents and describe their versions.
+type VersionedResolver interface {
+	resolver.Resolver
+	ResolveVersion(did string, parsed *parse.DID, opts ResolutionOptions) (*resolver.Document, *DocumentMetadata, error)
+}
+
+// DocumentResolver is a method resolver whose documents need properties resolver.Document cannot hold. Resolver
+// resolves its DIDs with ResolveDocument instead of through the resolver registry.
+type DocumentResolver interface {
+	resolver.Resolver
+	ResolveDocument(did string, parsed *parse.DID) (*Document, error)
+}
+
+// DIDResolver resolves a DID, or a DID URL such as a did:3 at a version, to its document.
+type DIDResolver interface {
+	Resolve(did string) (*ResolvedDID, error)
 }
 
 type Resolver struct {
-	client   threeid.HTTPClient
-	registry resolver.Registry
+	client    threeid.HTTPClient
+	registry  resolver.Registry
+	methods   map[string]bool
+	versioned map[string]VersionedResolver
+	documents map[string]DocumentResolver
 }
 
-// CreateDefaultResolver The default resolver contains both a did:key and did:3 resolver
+// CreateDefaultResolver The default resolver contains a did:key, did:jwk, did:pkh and did:3 resolver
 func CreateDefaultResolver(baseURL string) Resolver {
-	return CreateDIDResolver(baseURL, keys.New(), threeid.New())
+	return CreateDIDResolver(baseURL, NewKeyResolver(), NewJWKResolver(), NewPKHResolver(), threeid.New())
 }
 
 func CreateDIDResolver(baseURL string, resolvers ...resolver.Resolver) Resolver {
 	client := threeid.HTTPClient{APIURL: baseURL}
 	registry := resolver.New(resolvers, false)
+	methods := make(map[string]bool)
+	versioned := make(map[string]VersionedResolver)
+	documents := make(map[string]DocumentResolver)
+	for _, methodResolver := range resolvers {
+		methods[methodResolver.Method()] = true
+		if v, ok := methodResolver.(VersionedResolver); ok {
+			versioned[v.Method()] = v
+		}
+		if d, ok := methodResolver.(DocumentResolver); ok {
+			documents[d.Method()] = d
+		}
+	}
 	return Resolver{
-		client:   client,
-		registry: registry,
+		client:    client,
+		registry:  registry,
+		methods:   methods,
+		versioned: versioned,
+		documents: documents,
 	}
 }
 
 func (r Resolver) Resolve(did string) (*ResolvedDID, error) {
+	return r.ResolveWithOptions(did, ResolutionOptions{})
+}
+
+// ResolveWithOptions resolves did at the version selected by opts. Only methods with a VersionedResolver support
+// versions and report document metadata. Errors are ResolutionErrors.
+func (r Resolver) ResolveWithOptions(did string, opts ResolutionOptions) (*ResolvedDID, error) {
+	if opts.VersionID != "" && !opts.VersionTime.IsZero() {
+		return nil, resolutionError(InvalidDIDError, errors.New("versionId and versionTime cannot both be set"))
+	}
+	parsed, err := r.registry.Parse(did)
+	if err != nil {
+		return nil, resolutionError(InvalidDIDError, err)
+	}
+	if !r.methods[parsed.Method] {
+		return nil, resolutionError(MethodNotSupportedError, fmt.Errorf("unknown did method: '%s'", parsed.Method))
+	}
+	versioned, ok := r.versioned[parsed.Method]
+	if !ok {
+		if opts.isSet() {
+			return nil, resolutionError(MethodNotSupportedError, fmt.Errorf("did method<%s> does not support versions", parsed.Method))
+		}
+		if documents, ok := r.documents[parsed.Method]; ok {
+			document, err := documents.ResolveDocument(parsed.String(), parsed)
+			if err != nil {
+				return nil, methodError(err)
+			}
+			if document == nil {
+				return nil, resolutionError(NotFoundError, fmt.Errorf("did<%s> not able to be resolved: %s", did, NotFoundError))
+			}
+			return &ResolvedDID{Document: *document}, nil
+		}
+		return r.resolve(did)
+	}
+
+	document, documentMetadata, err := versioned.ResolveVersion(parsed.String(), parsed, opts)
+	if err != nil {
+		return nil, methodError(err)
+	}
+	if document == nil {
+		return nil, resolutionError(NotFoundError, fmt.Errorf("did<%s> not able to be resolved: %s", did, NotFoundError))
+	}
+	return &ResolvedDID{
+		Document:         fromResolverDocument(*document),
+		DocumentMetadata: *documentMetadata,
+	}, nil
+}
+
+// methodError keeps the ResolutionErrors of method resolvers; any other error is a failure to resolve.
+func methodError(err error) error {
+	var resolutionErr *ResolutionError
+	if errors.As(err, &resolutionErr) {
+		return err
+	}
+	return resolutionError(InternalError, err)
+}
+
+func (r Resolver) resolve(did string) (*ResolvedDID, error) {
 	resolvedMetadata, document, documentMetadata, err := r.registry.Resolve(did, nil)
 	if err != nil {
-		return nil, err
+		// the registry reports every failure of a method resolver as invalidDid; method resolvers mark the DIDs they
+		// cannot parse themselves, anything else is a failure to resolve
+		return nil, methodError(err)
 	}
 	if document == nil {
-		return nil, fmt.Errorf("did<%s> not able to be resolved: %s", did, resolvedMetadata.Error)
+		return nil, resolutionError(NotFoundError, fmt.Errorf("did<%s> not able to be resolved: %s", did, resolvedMetadata.Error))
 	}
 	return &ResolvedDID{
 		ResolutionMetadata: resolvedMetadata,
-		Document:           *document,
-		DocumentMetadata:   documentMetadata,
+		Document:           fromResolverDocument(*document),
+		DocumentMetadata:   DocumentMetadata{DocumentMetadata: documentMetadata},
 	}, nil
 }
+
+var _ DIDResolver = Resolver{}
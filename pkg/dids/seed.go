@@ -0,0 +1,116 @@
+package dids
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"github.com/tyler-smith/go-bip39"
+	"strconv"
+	"strings"
+)
+
+// https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+// https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+
+const (
+	// SeedSize is the size of the seed of an Ed25519 key
+	SeedSize = ed25519.SeedSize
+
+	hardenedOffset = 0x80000000
+	slip10Curve    = "ed25519 seed"
+)
+
+// NewMnemonic generates a random 24 word BIP39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// CreateDIDKeyFromSeed deterministically creates an Ed25519 did:key and its private key from a 32 byte seed.
+func CreateDIDKeyFromSeed(seed []byte) (*string, ed25519.PrivateKey, error) {
+	if len(seed) != SeedSize {
+		return nil, nil, fmt.Errorf("invalid seed length<%d>, expected %d", len(seed), SeedSize)
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	did, err := CreateDIDKey(privateKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	return did, privateKey, nil
+}
+
+// CreateDIDKeyFromMnemonic deterministically creates an Ed25519 did:key and its private key from a BIP39 mnemonic and
+// optional passphrase. The key is derived along path, such as "m/44'/0'/0'", with SLIP-0010; every segment of the path
+// must be hardened. An empty path uses the master key.
+func CreateDIDKeyFromMnemonic(mnemonic, passphrase, path string) (*string, ed25519.PrivateKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	keySeed, err := DeriveEd25519Seed(seed, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return CreateDIDKeyFromSeed(keySeed)
+}
+
+// DeriveEd25519Seed derives the Ed25519 key seed at path from a master seed, such as a BIP39 seed, with SLIP-0010.
+func DeriveEd25519Seed(masterSeed []byte, path string) ([]byte, error) {
+	indices, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, chainCode := slip10Master(masterSeed)
+	for _, index := range indices {
+		key, chainCode = slip10Child(key, chainCode, index)
+	}
+	return key, nil
+}
+
+func slip10Master(seed []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, []byte(slip10Curve))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+func slip10Child(key, chainCode []byte, index uint32) ([]byte, []byte) {
+	data := make([]byte, 0, 1+len(key)+4)
+	data = append(data, 0)
+	data = append(data, key...)
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+// parseDerivationPath parses a path of hardened indices like m/44'/0'/1'. Ed25519 has no unhardened derivation.
+func parseDerivationPath(path string) ([]uint32, error) {
+	if path == "" || path == "m" {
+		return nil, nil
+	}
+	segments := strings.Split(path, "/")
+	if segments[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path<%s>: must start with m", path)
+	}
+	indices := make([]uint32, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		if !strings.HasSuffix(segment, "'") && !strings.HasSuffix(strings.ToLower(segment), "h") {
+			return nil, fmt.Errorf("invalid derivation path<%s>: segment<%s> is not hardened", path, segment)
+		}
+		index, err := strconv.ParseUint(segment[:len(segment)-1], 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path<%s>: %w", path, err)
+		}
+		indices = append(indices, uint32(index)+hardenedOffset)
+	}
+	return indices, nil
+}
@@ -0,0 +1,76 @@
+package dids
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+	"strings"
+	"testing"
+)
+
+func TestCreateDIDKeyFromSeed(t *testing.T) {
+	seed := make([]byte, SeedSize)
+	seed[0] = 1
+	did, sk, err := CreateDIDKeyFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, seed, sk.Seed())
+
+	again, _, err := CreateDIDKeyFromSeed(seed)
+	assert.NoError(t, err)
+	assert.Equal(t, *did, *again)
+
+	_, _, err = CreateDIDKeyFromSeed(seed[:16])
+	assert.Error(t, err)
+}
+
+func TestDeriveEd25519Seed(t *testing.T) {
+	// test vector 1 for ed25519 https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+	master, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	assert.NoError(t, err)
+	vectors := map[string]string{
+		"m":          "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		"m/0'":       "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		"m/0H/1H":    "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+		"m/0'/1'/2'": "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9",
+	}
+	for path, expected := range vectors {
+		seed, err := DeriveEd25519Seed(master, path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, hex.EncodeToString(seed), path)
+	}
+
+	for _, path := range []string{"m/0", "44'/0'", "m/x'", "m/2147483648'"} {
+		_, err := DeriveEd25519Seed(master, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestCreateDIDKeyFromMnemonic(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	// https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		hex.EncodeToString(bip39.NewSeed(mnemonic, "TREZOR")))
+
+	first, _, err := CreateDIDKeyFromMnemonic(mnemonic, "TREZOR", "m/44'/0'/0'")
+	assert.NoError(t, err)
+	again, _, err := CreateDIDKeyFromMnemonic(mnemonic, "TREZOR", "m/44'/0'/0'")
+	assert.NoError(t, err)
+	assert.Equal(t, *first, *again)
+
+	second, _, err := CreateDIDKeyFromMnemonic(mnemonic, "TREZOR", "m/44'/0'/1'")
+	assert.NoError(t, err)
+	assert.NotEqual(t, *first, *second)
+	otherPassphrase, _, err := CreateDIDKeyFromMnemonic(mnemonic, "", "m/44'/0'/0'")
+	assert.NoError(t, err)
+	assert.NotEqual(t, *first, *otherPassphrase)
+
+	_, _, err = CreateDIDKeyFromMnemonic(strings.Replace(mnemonic, "about", "abandon", 1), "", "")
+	assert.Error(t, err)
+
+	generated, err := NewMnemonic()
+	assert.NoError(t, err)
+	assert.Len(t, strings.Fields(generated), 24)
+	_, _, err = CreateDIDKeyFromMnemonic(generated, "", "")
+	assert.NoError(t, err)
+}
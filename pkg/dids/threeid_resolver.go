@@ -0,0 +1,246 @@
+package dids
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	parse "github.com/ockam-network/did"
+	"github.com/textileio/go-did-resolver/resolver"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// https://github.com/ceramicnetwork/CIP/blob/main/CIPs/CIP-79/CIP-79.md
+
+const (
+	ThreeIDPrefix = "did:3"
+
+	X25519MultiCodec           = multicodec.Code(0xec)
+	Ed25519VerificationKey2018 = "Ed25519VerificationKey2018"
+	X25519KeyAgreementKey2019  = "X25519KeyAgreementKey2019"
+)
+
+// ThreeIDResolver resolves did:3 DIDs by loading their 3ID tile through a Ceramic node.
+type ThreeIDResolver struct {
+	ceramic api.CeramicAPI
+}
+
+func NewThreeIDResolver(ceramic api.CeramicAPI) *ThreeIDResolver {
+	return &ThreeIDResolver{ceramic: ceramic}
+}
+
+// CreateCeramicResolver creates a resolver for did:key, did:jwk, did:pkh and for did:3 through the given Ceramic node.
+func CreateCeramicResolver(ceramic api.CeramicAPI) Resolver {
+	return CreateDIDResolver("", NewKeyResolver(), NewJWKResolver(), NewPKHResolver(), NewThreeIDResolver(ceramic))
+}
+
+func (r *ThreeIDResolver) Method() string {
+	return "3"
+}
+
+func (r *ThreeIDResolver) Resolve(did string, parsed *parse.DID, _ resolver.Resolver) (*resolver.Document, error) {
+	document, _, err := r.ResolveVersion(did, parsed, ResolutionOptions{})
+	return document, err
+}
+
+// ResolveVersion resolves the 3ID at the version selected by opts or, if opts are empty, by the versionId or
+// versionTime query parameters of the DID URL. Versions are the commits of the 3ID tile, and version times are the
+// times their anchors were recorded.
+func (r *ThreeIDResolver) ResolveVersion(did string, parsed *parse.DID, opts ResolutionOptions) (*resolver.Document, *DocumentMetadata, error) {
+	if parsed.Method != r.Method() {
+		return nil, nil, fmt.Errorf("unknown did method: '%s'", parsed.Method)
+	}
+	streamID, err := streams.ParseStreamID(parsed.ID)
+	if err != nil {
+		return nil, nil, resolutionError(InvalidDIDError, fmt.Errorf("invalid did:3<%s>: %w", did, err))
+	}
+	if !opts.isSet() {
+		if opts, err = queryOptions(parsed.Query); err != nil {
+			return nil, nil, resolutionError(InvalidDIDError, fmt.Errorf("invalid query of did<%s>: %w", did, err))
+		}
+	}
+
+	latest, err := r.load(streamID.String())
+	if err != nil || latest == nil {
+		return nil, nil, err
+	}
+	index, err := versionIndex(latest.Log, opts)
+	if err != nil {
+		return nil, nil, resolutionError(NotFoundError, fmt.Errorf("could not resolve did<%s>: %w", did, err))
+	}
+	state := latest
+	if index < len(latest.Log)-1 {
+		commit, err := cid.Decode(latest.Log[index].CID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if state, err = r.load(streamID.AtCommit(commit).String()); err != nil {
+			return nil, nil, err
+		}
+		if state == nil {
+			return nil, nil, resolutionError(NotFoundError, fmt.Errorf("version<%s> of did<%s> not found", commit, did))
+		}
+	}
+
+	document, err := threeIDDocument(fmt.Sprintf("%s:%s", ThreeIDPrefix, parsed.ID), *state)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata := versionMetadata(latest.Log, index)
+	return document, &metadata, nil
+}
+
+// load returns the state of a stream or commit, or nil if the node does not know it.
+func (r *ThreeIDResolver) load(id string) (*streams.StreamState, error) {
+	resp, err := r.ceramic.GetStreamState(api.StreamStateRequest{StreamID: id})
+	if err != nil {
+		return nil, fmt.Errorf("could not load 3ID<%s>: %w", id, err)
+	}
+	if resp.ResponseCode != http.StatusOK && resp.ResponseCode != http.StatusNotFound {
+		return nil, fmt.Errorf("could not load 3ID<%s>: status %d", id, resp.ResponseCode)
+	}
+	if resp.ResponseCode == http.StatusNotFound || len(resp.Response.Log) == 0 {
+		return nil, nil
+	}
+	return &resp.Response, nil
+}
+
+func queryOptions(query string) (ResolutionOptions, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return ResolutionOptions{}, err
+	}
+	opts := ResolutionOptions{VersionID: values.Get("versionId")}
+	if versionTime := values.Get("versionTime"); versionTime != "" {
+		if opts.VersionTime, err = time.Parse(time.RFC3339, versionTime); err != nil {
+			return ResolutionOptions{}, fmt.Errorf("invalid versionTime<%s>: %w", versionTime, err)
+		}
+	}
+	if opts.VersionID != "" && !opts.VersionTime.IsZero() {
+		return ResolutionOptions{}, errors.New("versionId and versionTime cannot both be set")
+	}
+	return opts, nil
+}
+
+// versionIndex finds the log entry of the selected version. A version time selects the last commit anchored at or
+// before it, or the genesis commit if there is none.
+func versionIndex(log []streams.LogEntry, opts ResolutionOptions) (int, error) {
+	switch {
+	case opts.VersionID != "":
+		for i, entry := range log {
+			if entry.CID == opts.VersionID {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("version<%s> not found", opts.VersionID)
+	case !opts.VersionTime.IsZero():
+		index, at := 0, opts.VersionTime.Unix()
+		for i, entry := range log {
+			if entry.Type == streams.AnchorCommitType && int64(entry.Timestamp) <= at {
+				index = i
+			}
+		}
+		return index, nil
+	default:
+		return len(log) - 1, nil
+	}
+}
+
+// versionMetadata describes the version at index of a log: when the document was created and last updated, and the
+// version and anchor time of the update that followed it.
+func versionMetadata(log []streams.LogEntry, index int) DocumentMetadata {
+	var metadata DocumentMetadata
+	metadata.VersionID = log[index].CID
+	if index+1 < len(log) {
+		metadata.NextVersionID = log[index+1].CID
+	}
+	for i, entry := range log {
+		if entry.Type != streams.AnchorCommitType {
+			continue
+		}
+		anchoredAt := time.Unix(int64(entry.Timestamp), 0).UTC().Format(MetadataTimeFormat)
+		if metadata.Created == "" {
+			metadata.Created = anchoredAt
+		}
+		if i <= index {
+			metadata.Updated = anchoredAt
+		} else if metadata.NextUpdate == "" {
+			metadata.NextUpdate = anchoredAt
+		}
+	}
+	return metadata
+}
+
+// threeIDDocument builds the document of a 3ID from its tile, whose content lists its keys as did:key fingerprints.
+func threeIDDocument(did string, state streams.StreamState) (*resolver.Document, error) {
+	content := state.Content
+	if streams.HasPendingChanges(state) {
+		content = state.Next.Content
+	}
+	var tile struct {
+		PublicKeys map[string]string `json:"publicKeys"`
+	}
+	if content != nil {
+		if err := json.Unmarshal(*content, &tile); err != nil {
+			return nil, fmt.Errorf("invalid 3ID document<%s>: %w", did, err)
+		}
+	}
+
+	document := resolver.Document{
+		Context: []string{"https://w3id.org/did/v1"},
+		ID:      did,
+	}
+	for _, name := range sortedNames(tile.PublicKeys) {
+		fingerprint := tile.PublicKeys[name]
+		codec, keyBytes, err := decodeDIDKey(fmt.Sprintf("%s:%s", DIDPrefix, fingerprint))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key<%s> of 3ID<%s>: %w", name, did, err)
+		}
+		keyMultibase, err := multibase.Encode(Base58BTCMultiBase, keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		method := resolver.VerificationMethod{
+			ID:                 fmt.Sprintf("%s#%s", did, name),
+			Controller:         did,
+			PublicKeyMultibase: keyMultibase,
+		}
+		switch codec {
+		case Ed25519MultiCodec:
+			method.Type = Ed25519VerificationKey2018
+		case Secp256k1MultiCodec:
+			method.Type = Secp256k1VerificationKey2019
+		case P256MultiCodec:
+			method.Type = P256Key2021
+		case P384MultiCodec:
+			method.Type = P384Key2021
+		case X25519MultiCodec:
+			method.Type = X25519KeyAgreementKey2019
+			document.KeyAgreement = append(document.KeyAgreement, method)
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported key type<%s> of 3ID<%s>", codec, did)
+		}
+		document.VerificationMethod = append(document.VerificationMethod, method)
+		document.Authentication = append(document.Authentication, method)
+	}
+	return &document, nil
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ VersionedResolver = (*ThreeIDResolver)(nil)
@@ -0,0 +1,202 @@
+package dids
+
+import (
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-varint"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThreeIDResolver(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	resolver := CreateCeramicResolver(ceramic)
+
+	signingKey, _, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	signingDID, err := CreateSecp256k1DIDKey(signingKey)
+	assert.NoError(t, err)
+	encryptionKey, err := multibase.Encode(multibase.Base58BTC, append(varint.ToUvarint(uint64(X25519MultiCodec)), make([]byte, 32)...))
+	assert.NoError(t, err)
+	publicKeys := map[string]string{
+		"signing":    strings.TrimPrefix(*signingDID, DIDPrefix+":"),
+		"encryption": encryptionKey,
+	}
+
+	createResp, err := ceramic.CreateStream(api.CreateStreamRequest{Genesis: map[string]interface{}{
+		"header": map[string]interface{}{"controllers": []string{*signingDID}, "family": "3id"},
+		"data":   map[string]interface{}{"publicKeys": publicKeys},
+	}})
+	assert.NoError(t, err)
+	did := ThreeIDPrefix + ":" + createResp.Response.ID
+
+	t.Run("resolve", func(tt *testing.T) {
+		resolved, err := resolver.Resolve(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, did, resolved.ID)
+		assert.Len(tt, resolved.VerificationMethod, 1)
+		assert.Equal(tt, did+"#signing", resolved.VerificationMethod[0].ID)
+		assert.Equal(tt, Secp256k1VerificationKey2019, resolved.VerificationMethod[0].Type)
+		assert.Equal(tt, resolved.VerificationMethod, resolved.Authentication)
+		assert.Len(tt, resolved.KeyAgreement, 1)
+		assert.Equal(tt, X25519KeyAgreementKey2019, resolved.KeyAgreement[0].Type)
+	})
+
+	t.Run("resolve at version", func(tt *testing.T) {
+		delete(publicKeys, "encryption")
+		commit, err := streams.NewPatchCommit(createResp.Response.State, map[string]interface{}{"publicKeys": publicKeys}, streams.PatchOpts{})
+		assert.NoError(tt, err)
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: createResp.Response.ID, Commit: commit})
+		assert.NoError(tt, err)
+
+		latest, err := resolver.Resolve(did)
+		assert.NoError(tt, err)
+		assert.Empty(tt, latest.KeyAgreement)
+
+		genesis := createResp.Response.State.Log[0].CID
+		atGenesis, err := resolver.Resolve(did + "?versionId=" + genesis)
+		assert.NoError(tt, err)
+		assert.Len(tt, atGenesis.KeyAgreement, 1)
+	})
+
+	t.Run("unknown 3ID", func(tt *testing.T) {
+		_, err := resolver.Resolve("did:3:k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl")
+		assert.Error(tt, err)
+		_, err = resolver.Resolve("did:3:bad")
+		assert.Error(tt, err)
+	})
+
+	t.Run("node fails", func(tt *testing.T) {
+		failing := CreateCeramicResolver(failingCeramic{ceramic})
+		_, err := failing.Resolve(did)
+		var resolutionErr *ResolutionError
+		assert.True(tt, errors.As(err, &resolutionErr))
+		assert.Equal(tt, InternalError, resolutionErr.Code)
+		assert.Contains(tt, err.Error(), "status 500")
+	})
+}
+
+// failingCeramic answers every stream state request with a server error, as the HTTP client does.
+type failingCeramic struct {
+	*testutil.MemoryCeramic
+}
+
+func (failingCeramic) GetStreamState(api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	return &api.StreamStateResponse{ResponseCode: http.StatusInternalServerError}, nil
+}
+
+func TestResolveVersion(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	resolver := CreateCeramicResolver(ceramic)
+
+	var fingerprints []string
+	for i := 0; i < 2; i++ {
+		publicKey, _, err := internal.GenerateEd25519Key()
+		assert.NoError(t, err)
+		did, err := CreateDIDKey(publicKey)
+		assert.NoError(t, err)
+		fingerprints = append(fingerprints, strings.TrimPrefix(*did, DIDPrefix+":"))
+	}
+	versions := []map[string]string{
+		{"a": fingerprints[0]},
+		{"a": fingerprints[0], "b": fingerprints[1]},
+		{"b": fingerprints[1]},
+	}
+
+	createResp, err := ceramic.CreateStream(api.CreateStreamRequest{Genesis: map[string]interface{}{
+		"header": map[string]interface{}{"controllers": []string{DIDPrefix + ":" + fingerprints[0]}},
+		"data":   map[string]interface{}{"publicKeys": versions[0]},
+	}})
+	assert.NoError(t, err)
+	streamID := createResp.Response.ID
+	did := ThreeIDPrefix + ":" + streamID
+	update := func(version int) {
+		state, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID})
+		assert.NoError(t, err)
+		commit, err := streams.NewPatchCommit(state.Response, map[string]interface{}{"publicKeys": versions[version]}, streams.PatchOpts{})
+		assert.NoError(t, err)
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: streamID, Commit: commit})
+		assert.NoError(t, err)
+	}
+
+	// genesis, anchored at 1000, second version, anchored at 2000, third version pending
+	_, err = ceramic.Anchor(streamID, streams.AnchorProof{ChainID: "inmemory:12345", BlockTimestamp: 1000})
+	assert.NoError(t, err)
+	update(1)
+	_, err = ceramic.Anchor(streamID, streams.AnchorProof{ChainID: "inmemory:12345", BlockTimestamp: 2000})
+	assert.NoError(t, err)
+	update(2)
+	state, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID})
+	assert.NoError(t, err)
+	log := state.Response.Log
+	assert.Len(t, log, 5)
+
+	keyNames := func(resolved *ResolvedDID) []string {
+		var names []string
+		for _, method := range resolved.VerificationMethod {
+			names = append(names, strings.TrimPrefix(method.ID, did+"#"))
+		}
+		return names
+	}
+
+	t.Run("latest", func(tt *testing.T) {
+		resolved, err := resolver.Resolve(did)
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{"b"}, keyNames(resolved))
+		assert.Equal(tt, log[4].CID, resolved.VersionID)
+		assert.Equal(tt, "1970-01-01T00:16:40Z", resolved.Created)
+		assert.Equal(tt, "1970-01-01T00:33:20Z", resolved.Updated)
+		assert.Empty(tt, resolved.NextVersionID)
+		assert.Empty(tt, resolved.NextUpdate)
+	})
+
+	t.Run("version time", func(tt *testing.T) {
+		resolved, err := resolver.ResolveWithOptions(did, ResolutionOptions{VersionTime: time.Unix(1500, 0)})
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{"a"}, keyNames(resolved))
+		assert.Equal(tt, log[1].CID, resolved.VersionID)
+		assert.Equal(tt, "1970-01-01T00:16:40Z", resolved.Updated)
+		assert.Equal(tt, log[2].CID, resolved.NextVersionID)
+		assert.Equal(tt, "1970-01-01T00:33:20Z", resolved.NextUpdate)
+
+		resolved, err = resolver.Resolve(did + "?versionTime=1970-01-01T00:33:20Z")
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{"a", "b"}, keyNames(resolved))
+		assert.Equal(tt, log[3].CID, resolved.VersionID)
+
+		// before the first anchor only the genesis version is known
+		resolved, err = resolver.ResolveWithOptions(did, ResolutionOptions{VersionTime: time.Unix(500, 0)})
+		assert.NoError(tt, err)
+		assert.Equal(tt, log[0].CID, resolved.VersionID)
+		assert.Empty(tt, resolved.Updated)
+		assert.Equal(tt, "1970-01-01T00:16:40Z", resolved.NextUpdate)
+	})
+
+	t.Run("version id", func(tt *testing.T) {
+		resolved, err := resolver.ResolveWithOptions(did, ResolutionOptions{VersionID: log[2].CID})
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{"a", "b"}, keyNames(resolved))
+		assert.Equal(tt, log[2].CID, resolved.VersionID)
+		assert.Equal(tt, log[3].CID, resolved.NextVersionID)
+		assert.Equal(tt, "1970-01-01T00:33:20Z", resolved.NextUpdate)
+
+		_, err = resolver.ResolveWithOptions(did, ResolutionOptions{VersionID: "bafyreihtdxfb6cpcvomm2c2elm3re2onqaix6frq4nbg45eaqszh5mifre"})
+		assert.Error(tt, err)
+	})
+
+	t.Run("invalid options", func(tt *testing.T) {
+		_, err := resolver.ResolveWithOptions(did, ResolutionOptions{VersionID: log[2].CID, VersionTime: time.Unix(1500, 0)})
+		assert.Error(tt, err)
+		_, err = resolver.ResolveWithOptions(DIDPrefix+":"+fingerprints[0], ResolutionOptions{VersionID: log[2].CID})
+		assert.Error(tt, err)
+		_, err = resolver.Resolve(did + "?versionTime=yesterday")
+		assert.Error(tt, err)
+	})
+}
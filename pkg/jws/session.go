@@ -0,0 +1,126 @@
+package jws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/cacao"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"time"
+)
+
+var ErrOutsideCapability = errors.New("commit is outside the resources granted by the cacao")
+
+// SessionSigner signs commits with a session key on behalf of the issuer of a CACAO delegating to that key. Its DID
+// is the issuer's, usually a did:pkh, so it can update streams the issuer controls.
+type SessionSigner struct {
+	key        KeySigner
+	capability cacao.Cacao
+	ceramic    api.CeramicAPI
+	block      []byte
+	link       cid.Cid
+}
+
+// NewSessionSigner creates a signer from a session key and a CACAO whose audience is the session key's DID. ceramic
+// looks up the family of streams the CACAO grants by family; without it only streams granted by ID can be updated.
+func NewSessionSigner(key KeySigner, capability cacao.Cacao, ceramic api.CeramicAPI) (*SessionSigner, error) {
+	if capability.Payload.Audience != key.DID() {
+		return nil, fmt.Errorf("cacao audience<%s> is not the session key<%s>", capability.Payload.Audience, key.DID())
+	}
+	if err := capability.Verify(time.Now()); err != nil {
+		return nil, err
+	}
+	block, err := capability.Encode()
+	if err != nil {
+		return nil, err
+	}
+	link, err := capability.CID()
+	if err != nil {
+		return nil, err
+	}
+	return &SessionSigner{key: key, capability: capability, ceramic: ceramic, block: block, link: link}, nil
+}
+
+func (s SessionSigner) DID() string {
+	return s.capability.Payload.Issuer
+}
+
+func (s SessionSigner) Capability() cacao.Cacao {
+	return s.capability
+}
+
+// SignCommit signs the commit with the session key, referencing the CACAO from the JWS header and attaching its
+// block. It refuses commits the CACAO does not allow: genesis commits are checked by family, and updates by stream ID
+// or else by the family of the stream, loaded from the ceramic node.
+func (s SessionSigner) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	if err := s.capability.Verify(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.checkResources(payload); err != nil {
+		return nil, err
+	}
+	header := s.key.Header()
+	header.Capability = cacao.IPFSPrefix + s.link.String()
+	signed, err := signCommit(header, payload, s.key.Sign)
+	if err != nil {
+		return nil, err
+	}
+	signed.CacaoBlock = base64.StdEncoding.EncodeToString(s.block)
+	return signed, nil
+}
+
+func (s SessionSigner) checkResources(payload interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var commit streams.RawCommit
+	if err := json.Unmarshal(payloadBytes, &commit); err != nil {
+		return errors.New("commit payload must be an object")
+	}
+	if commit.ID == "" {
+		if !s.capability.Allows("", commit.Header.Family) {
+			return fmt.Errorf("genesis commit of family<%s>: %w", commit.Header.Family, ErrOutsideCapability)
+		}
+		return nil
+	}
+	genesis, err := cid.Decode(commit.ID)
+	if err != nil {
+		return fmt.Errorf("commit field<id> is not a CID: %w", err)
+	}
+	// commits only name their genesis CID, which starts a stream of any type
+	for _, streamType := range streams.StreamTypes {
+		if s.capability.Allows(streams.NewStreamID(streamType, genesis).String(), "") {
+			return nil
+		}
+	}
+	if !s.capability.GrantsFamilies() {
+		return fmt.Errorf("commit to stream with genesis<%s>: %w", genesis, ErrOutsideCapability)
+	}
+	if s.ceramic == nil {
+		return fmt.Errorf("cannot look up the family of the stream with genesis<%s> without a ceramic node: %w", genesis, ErrOutsideCapability)
+	}
+	streamID, state, err := api.LoadGenesisStream(s.ceramic, genesis)
+	if err != nil {
+		return err
+	}
+	metadata := state.Metadata
+	if streams.HasPendingChanges(*state) {
+		metadata = state.Next.Metadata
+	}
+	families := []string{metadata.Family}
+	if commit.Header.Family != "" && commit.Header.Family != metadata.Family {
+		families = append(families, commit.Header.Family)
+	}
+	for _, family := range families {
+		if !s.capability.Allows(streamID, family) {
+			return fmt.Errorf("commit to stream<%s> of family<%s>: %w", streamID, family, ErrOutsideCapability)
+		}
+	}
+	return nil
+}
+
+var _ Signer = (*SessionSigner)(nil)
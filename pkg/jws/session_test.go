@@ -0,0 +1,183 @@
+package jws
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/cacao"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/tile"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// familyCeramic reports every stream as belonging to family, to have a session signer sign updates its CACAO does
+// not allow.
+type familyCeramic struct {
+	*testutil.MemoryCeramic
+	family string
+}
+
+func (c familyCeramic) GetStreamState(req api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	resp, err := c.MemoryCeramic.GetStreamState(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Response.Metadata.Family = c.family
+	resp.Response.Next.Metadata.Family = c.family
+	return resp, nil
+}
+
+func TestSessionSigner(t *testing.T) {
+	publicKey, accountKey, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	account := dids.CreatePKHDID("1", dids.EthereumAddress(publicKey))
+	session := newTestSigner(t)
+
+	now := time.Now().UTC()
+	newCapability := func(resources ...string) cacao.Cacao {
+		c, err := cacao.SignEIP191(cacao.Payload{
+			Domain:    "app.example",
+			Issuer:    account,
+			Audience:  session.DID(),
+			Version:   "1",
+			Nonce:     "abcdef",
+			IssuedAt:  now.Format(time.RFC3339),
+			ExpiresAt: now.Add(time.Hour).Format(time.RFC3339),
+			Resources: resources,
+		}, accountKey)
+		assert.NoError(t, err)
+		return *c
+	}
+	ceramic := testutil.NewMemoryCeramic()
+	signer, err := NewSessionSigner(session, newCapability("ceramic://*?family=notes"), ceramic)
+	assert.NoError(t, err)
+	assert.Equal(t, account, signer.DID())
+
+	verifier := NewVerifier(dids.CreateCeramicResolver(ceramic))
+	doc, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+		Controllers: []string{account},
+		Family:      "notes",
+	}, streams.DefaultCreateOpts)
+	assert.NoError(t, err)
+
+	t.Run("update on behalf of the account", func(tt *testing.T) {
+		assert.NoError(tt, doc.Update(map[string]string{"title": "second"}, signer, streams.DefaultUpdateOpts))
+		commits := loadCommits(tt, ceramic, doc.ID())
+		assert.NotEmpty(tt, commits[1].CapabilityBlock)
+		assert.NoError(tt, verifier.VerifyCommits(streams.Tile, commits))
+
+		header, err := decodeHeader(commits[1].Envelope.Signatures[0].Protected)
+		assert.NoError(tt, err)
+		assert.True(tt, strings.HasPrefix(header.Capability, cacao.IPFSPrefix))
+		assert.True(tt, strings.HasPrefix(header.KeyID, session.DID()))
+	})
+
+	t.Run("outside the granted resources", func(tt *testing.T) {
+		_, err := signer.SignCommit(streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+			Controllers: []string{account},
+			Family:      "secrets",
+		}}})
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+
+		single, err := NewSessionSigner(session, newCapability("ceramic://kjzl6cwe1jw14a8e6ev2lmcnsnbyo4j0iizgs9wwwcvn2r3wiq7pu6qhzkcktly"), ceramic)
+		assert.NoError(tt, err)
+		err = doc.Update(map[string]string{"title": "third"}, single, streams.DefaultUpdateOpts)
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+	})
+
+	t.Run("stream of another family", func(tt *testing.T) {
+		other, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{account},
+			Family:      "secrets",
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		err = other.Update(map[string]string{"title": "second"}, signer, streams.DefaultUpdateOpts)
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+
+		// moving a granted stream into another family is refused as well
+		commit, err := streams.NewPatchCommit(doc.State(), map[string]string{"title": "second"}, streams.PatchOpts{})
+		assert.NoError(tt, err)
+		commit.Header.Family = "secrets"
+		_, err = signer.SignCommit(commit)
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+	})
+
+	t.Run("family grant without a ceramic node", func(tt *testing.T) {
+		offline, err := NewSessionSigner(session, newCapability("ceramic://*?family=notes"), nil)
+		assert.NoError(tt, err)
+		err = doc.Update(map[string]string{"title": "third"}, offline, streams.DefaultUpdateOpts)
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+	})
+
+	t.Run("caip-10 link granted by stream id", func(tt *testing.T) {
+		created, err := ceramic.CreateStream(api.CreateStreamRequest{
+			Type: int(streams.CAIP10Link),
+			Genesis: streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+				Controllers: []string{account},
+			}}},
+		})
+		assert.NoError(tt, err)
+		link, err := NewSessionSigner(session, newCapability("ceramic://"+created.Response.ID), nil)
+		assert.NoError(tt, err)
+
+		content := json.RawMessage(`"did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H"`)
+		commit, err := streams.NewPatchCommit(created.Response.State, &content, streams.PatchOpts{})
+		assert.NoError(tt, err)
+		signed, err := link.SignCommit(commit)
+		assert.NoError(tt, err)
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: created.Response.ID, Commit: signed})
+		assert.NoError(tt, err)
+		assert.NoError(tt, verifier.VerifyCommits(streams.CAIP10Link, loadCommits(tt, ceramic, created.Response.ID)))
+	})
+
+	t.Run("verifier checks the grant", func(tt *testing.T) {
+		other, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{account},
+			Family:      "secrets",
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		// a node misreporting the family gets the signer to sign, but not the verifier to accept
+		misled, err := NewSessionSigner(session, newCapability("ceramic://*?family=notes"), familyCeramic{ceramic, "notes"})
+		assert.NoError(tt, err)
+		assert.NoError(tt, other.Update(map[string]string{"title": "second"}, misled, streams.DefaultUpdateOpts))
+		err = verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, other.ID()))
+		assert.True(tt, errors.Is(err, ErrOutsideCapability))
+	})
+
+	t.Run("expired after the commit was anchored", func(tt *testing.T) {
+		anchored, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{account},
+			Family:      "notes",
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, anchored.Update(map[string]string{"title": "second"}, signer, streams.DefaultUpdateOpts))
+		proof := streams.AnchorProof{ChainID: "eip155:1", BlockNumber: 1, BlockTimestamp: uint64(now.Unix())}
+		_, err = ceramic.Anchor(anchored.ID(), proof)
+		assert.NoError(tt, err)
+
+		later := NewVerifier(dids.CreateCeramicResolver(ceramic))
+		later.now = func() time.Time { return now.Add(2 * time.Hour) }
+		commits := loadCommits(tt, ceramic, anchored.ID())
+		err = later.VerifyCommits(streams.Tile, commits)
+		assert.True(tt, errors.Is(err, cacao.ErrExpired))
+
+		commits[2].Proof = proof
+		assert.NoError(tt, later.VerifyCommits(streams.Tile, commits))
+	})
+
+	t.Run("invalid capabilities", func(tt *testing.T) {
+		_, err := NewSessionSigner(newTestSigner(tt), newCapability("ceramic://*"), ceramic)
+		assert.Error(tt, err)
+
+		expired := newCapability("ceramic://*")
+		expired.Payload.ExpiresAt = now.Add(-time.Minute).Format(time.RFC3339)
+		_, err = NewSessionSigner(session, expired, ceramic)
+		assert.Error(tt, err)
+	})
+}
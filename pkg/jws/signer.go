@@ -0,0 +1,269 @@
+package jws
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"strings"
+)
+
+// https://github.com/ceramicnetwork/CIP/blob/main/CIPs/CIP-82/CIP-82.md
+// https://ipld.io/specs/codecs/dag-jose/spec/
+
+const (
+	EdDSA  = "EdDSA"
+	ES256K = "ES256K"
+	ES256  = "ES256"
+	ES384  = "ES384"
+)
+
+// Signer signs commits on behalf of a DID. The signed commits can be sent as the commit of an ApplyCommitRequest or
+// the genesis of a CreateStreamRequest.
+type Signer interface {
+	DID() string
+	SignCommit(payload interface{}) (*streams.SignedCommit, error)
+}
+
+// KeySigner is a Signer holding its key, which can sign any JWS signing input. A KeySigner can be the session key of
+// a SessionSigner.
+type KeySigner interface {
+	Signer
+	Header() Header
+	Sign(signingInput []byte) ([]byte, error)
+}
+
+// Header is the protected header of a commit JWS. Capability is the ipfs:// URI of the CACAO that allows a session
+// key to sign for a controller.
+type Header struct {
+	Algorithm  string `json:"alg"`
+	KeyID      string `json:"kid"`
+	Capability string `json:"cap,omitempty"`
+}
+
+type Ed25519Signer struct {
+	did        string
+	privateKey ed25519.PrivateKey
+}
+
+// NewEd25519Signer creates a signer for the did:key of the given private key.
+func NewEd25519Signer(privateKey ed25519.PrivateKey) (*Ed25519Signer, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key length<%d>", len(privateKey))
+	}
+	did, err := dids.CreateDIDKey(privateKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	return &Ed25519Signer{did: *did, privateKey: privateKey}, nil
+}
+
+func (s Ed25519Signer) DID() string {
+	return s.did
+}
+
+func (s Ed25519Signer) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	return signCommit(s.Header(), payload, s.Sign)
+}
+
+func (s Ed25519Signer) Header() Header {
+	return Header{Algorithm: EdDSA, KeyID: didKeyID(s.did)}
+}
+
+func (s Ed25519Signer) Sign(signingInput []byte) ([]byte, error) {
+	return ed25519.Sign(s.privateKey, signingInput), nil
+}
+
+type Secp256k1Signer struct {
+	did        string
+	privateKey *btcec.PrivateKey
+}
+
+// NewSecp256k1Signer creates a signer for the did:key of the given private key.
+func NewSecp256k1Signer(privateKey *btcec.PrivateKey) (*Secp256k1Signer, error) {
+	if privateKey == nil {
+		return nil, errors.New("missing secp256k1 private key")
+	}
+	did, err := dids.CreateSecp256k1DIDKey(privateKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	return &Secp256k1Signer{did: *did, privateKey: privateKey}, nil
+}
+
+func (s Secp256k1Signer) DID() string {
+	return s.did
+}
+
+func (s Secp256k1Signer) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	return signCommit(s.Header(), payload, s.Sign)
+}
+
+func (s Secp256k1Signer) Header() Header {
+	return Header{Algorithm: ES256K, KeyID: didKeyID(s.did)}
+}
+
+func (s Secp256k1Signer) Sign(signingInput []byte) ([]byte, error) {
+	hash := sha256.Sum256(signingInput)
+	compact, err := btcecdsa.SignCompact(s.privateKey, hash[:], true)
+	if err != nil {
+		return nil, err
+	}
+	// drop the recovery byte, leaving R || S
+	return compact[1:], nil
+}
+
+// ECDSASigner signs with a P-256 key using ES256 or a P-384 key using ES384.
+type ECDSASigner struct {
+	did        string
+	alg        string
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewECDSASigner creates a signer for the did:key of the given private key.
+func NewECDSASigner(privateKey *ecdsa.PrivateKey) (*ECDSASigner, error) {
+	if privateKey == nil {
+		return nil, errors.New("missing ecdsa private key")
+	}
+	alg, err := curveAlgorithm(privateKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+	did, err := dids.CreateECDSADIDKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ECDSASigner{did: *did, alg: alg, privateKey: privateKey}, nil
+}
+
+func (s ECDSASigner) DID() string {
+	return s.did
+}
+
+func (s ECDSASigner) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	return signCommit(s.Header(), payload, s.Sign)
+}
+
+func (s ECDSASigner) Header() Header {
+	return Header{Algorithm: s.alg, KeyID: didKeyID(s.did)}
+}
+
+func (s ECDSASigner) Sign(signingInput []byte) ([]byte, error) {
+	r, sig, err := ecdsa.Sign(rand.Reader, s.privateKey, hashFor(s.alg, signingInput))
+	if err != nil {
+		return nil, err
+	}
+	// JWS signatures are R || S, each padded to the curve's byte size
+	size := (s.privateKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	sig.FillBytes(signature[size:])
+	return signature, nil
+}
+
+func curveAlgorithm(curve elliptic.Curve) (string, error) {
+	switch curve {
+	case elliptic.P256():
+		return ES256, nil
+	case elliptic.P384():
+		return ES384, nil
+	default:
+		return "", fmt.Errorf("unsupported curve<%s>", curve.Params().Name)
+	}
+}
+
+func hashFor(alg string, signingInput []byte) []byte {
+	if alg == ES384 {
+		hash := sha512.Sum384(signingInput)
+		return hash[:]
+	}
+	hash := sha256.Sum256(signingInput)
+	return hash[:]
+}
+
+// didKeyID names the single key of a did:key, whose fragment is the key's multibase encoding.
+func didKeyID(did string) string {
+	return did + "#" + strings.TrimPrefix(did, dids.DIDPrefix+":")
+}
+
+// signCommit encodes the payload as a dag-cbor block and signs its CID, producing a general serialization JWS whose
+// payload is the CID bytes.
+func signCommit(header Header, payload interface{}, sign func(signingInput []byte) ([]byte, error)) (*streams.SignedCommit, error) {
+	block, err := EncodeCommit(payload)
+	if err != nil {
+		return nil, err
+	}
+	link, err := dagcbor.CID(block)
+	if err != nil {
+		return nil, err
+	}
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(headerBytes)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(link.Bytes())
+	signature, err := sign([]byte(protected + "." + encodedPayload))
+	if err != nil {
+		return nil, fmt.Errorf("could not sign commit<%s>: %w", link, err)
+	}
+	return &streams.SignedCommit{
+		JWS: streams.DAGJWS{
+			Payload: encodedPayload,
+			Signatures: []streams.JWSSignature{{
+				Protected: protected,
+				Signature: base64.RawURLEncoding.EncodeToString(signature),
+			}},
+			Link: link.String(),
+		},
+		LinkedBlock: base64.StdEncoding.EncodeToString(block),
+	}, nil
+}
+
+// commitLinks are the commit fields that reference other commits and are links in the commit's block.
+var commitLinks = []string{"id", "prev", "proof"}
+
+// EncodeCommit encodes a commit payload, such as a streams.RawCommit, as a dag-cbor block. The string CIDs of the
+// commit's links are encoded as links.
+func EncodeCommit(payload interface{}) ([]byte, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payloadBytes))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, errors.New("commit payload must be an object")
+	}
+	for _, name := range commitLinks {
+		value, ok := fields[name].(string)
+		if !ok {
+			continue
+		}
+		if _, err := cid.Decode(value); err != nil {
+			return nil, fmt.Errorf("commit field<%s> is not a CID: %w", name, err)
+		}
+		fields[name] = map[string]string{"/": value}
+	}
+	return dagcbor.Encode(fields)
+}
+
+var (
+	_ KeySigner = (*Ed25519Signer)(nil)
+	_ KeySigner = (*Secp256k1Signer)(nil)
+	_ KeySigner = (*ECDSASigner)(nil)
+)
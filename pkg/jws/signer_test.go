@@ -0,0 +1,140 @@
+package jws
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/tile"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const testGenesisCID = "bafyreihtdxfb6cpcvomm2c2elm3re2onqaix6frq4nbg45eaqszh5mifre"
+
+func TestEd25519Signer(t *testing.T) {
+	pk, sk, err := internal.GenerateEd25519Key()
+	assert.NoError(t, err)
+	signer, err := NewEd25519Signer(sk)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signer.DID(), "did:key:z6Mk"))
+
+	data := json.RawMessage(`[{"op":"replace","path":"/title","value":"second"}]`)
+	commit := streams.RawCommit{ID: testGenesisCID, Prev: testGenesisCID, Data: &data}
+
+	t.Run("sign commit", func(tt *testing.T) {
+		signed, err := signer.SignCommit(commit)
+		assert.NoError(tt, err)
+		assert.Len(tt, signed.JWS.Signatures, 1)
+
+		var header Header
+		headerBytes, err := base64.RawURLEncoding.DecodeString(signed.JWS.Signatures[0].Protected)
+		assert.NoError(tt, err)
+		assert.NoError(tt, json.Unmarshal(headerBytes, &header))
+		assert.Equal(tt, EdDSA, header.Algorithm)
+		assert.Equal(tt, signer.DID()+"#"+strings.TrimPrefix(signer.DID(), "did:key:"), header.KeyID)
+
+		signature, err := base64.RawURLEncoding.DecodeString(signed.JWS.Signatures[0].Signature)
+		assert.NoError(tt, err)
+		signingInput := signed.JWS.Signatures[0].Protected + "." + signed.JWS.Payload
+		assert.True(tt, ed25519.Verify(pk, []byte(signingInput), signature))
+
+		// the payload is the CID of the linked block
+		block, err := base64.StdEncoding.DecodeString(signed.LinkedBlock)
+		assert.NoError(tt, err)
+		link, err := dagcbor.CID(block)
+		assert.NoError(tt, err)
+		assert.Equal(tt, link.String(), signed.JWS.Link)
+		payload, err := base64.RawURLEncoding.DecodeString(signed.JWS.Payload)
+		assert.NoError(tt, err)
+		assert.Equal(tt, link.Bytes(), payload)
+
+		// links are encoded as links
+		decoded, err := dagcbor.Decode(block)
+		assert.NoError(tt, err)
+		assert.IsType(tt, cid.Cid{}, decoded.(map[string]interface{})["prev"])
+	})
+
+	t.Run("invalid commits", func(tt *testing.T) {
+		_, err := signer.SignCommit(streams.RawCommit{ID: "not a cid"})
+		assert.Error(tt, err)
+		_, err = signer.SignCommit([]string{"not", "an", "object"})
+		assert.Error(tt, err)
+		_, err = NewEd25519Signer(ed25519.PrivateKey{1, 2, 3})
+		assert.Error(tt, err)
+	})
+
+	t.Run("update a tile", func(tt *testing.T) {
+		ceramic := testutil.NewMemoryCeramic()
+		doc, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{signer.DID()},
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+
+		assert.NoError(tt, doc.Update(map[string]string{"title": "second"}, signer, streams.DefaultUpdateOpts))
+		assert.JSONEq(tt, `{"title":"second"}`, string(*doc.Content().(*json.RawMessage)))
+		assert.Equal(tt, streams.SignedSigStatus, doc.State().Signature)
+	})
+}
+
+func TestSecp256k1Signer(t *testing.T) {
+	pk, sk, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	signer, err := NewSecp256k1Signer(sk)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signer.DID(), "did:key:zQ3s"))
+
+	signed, err := signer.SignCommit(streams.RawCommit{ID: testGenesisCID, Prev: testGenesisCID})
+	assert.NoError(t, err)
+	header, err := decodeHeader(signed.JWS.Signatures[0].Protected)
+	assert.NoError(t, err)
+	assert.Equal(t, ES256K, header.Algorithm)
+
+	signature, err := base64.RawURLEncoding.DecodeString(signed.JWS.Signatures[0].Signature)
+	assert.NoError(t, err)
+	assert.Len(t, signature, 64)
+	signingInput := []byte(signed.JWS.Signatures[0].Protected + "." + signed.JWS.Payload)
+	assert.NoError(t, verifySignature(ES256K, pk, signingInput, signature))
+	assert.Error(t, verifySignature(ES256K, pk, append(signingInput, '.'), signature))
+
+	_, err = NewSecp256k1Signer(nil)
+	assert.Error(t, err)
+}
+
+func TestECDSASigner(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		t.Run(curve.Params().Name, func(tt *testing.T) {
+			sk, err := ecdsa.GenerateKey(curve, rand.Reader)
+			assert.NoError(tt, err)
+			signer, err := NewECDSASigner(sk)
+			assert.NoError(tt, err)
+
+			signed, err := signer.SignCommit(streams.RawCommit{ID: testGenesisCID, Prev: testGenesisCID})
+			assert.NoError(tt, err)
+			header, err := decodeHeader(signed.JWS.Signatures[0].Protected)
+			assert.NoError(tt, err)
+			alg, err := curveAlgorithm(curve)
+			assert.NoError(tt, err)
+			assert.Equal(tt, alg, header.Algorithm)
+
+			signature, err := base64.RawURLEncoding.DecodeString(signed.JWS.Signatures[0].Signature)
+			assert.NoError(tt, err)
+			signingInput := []byte(signed.JWS.Signatures[0].Protected + "." + signed.JWS.Payload)
+			assert.NoError(tt, verifySignature(alg, &sk.PublicKey, signingInput, signature))
+			assert.Error(tt, verifySignature(alg, &sk.PublicKey, append(signingInput, '.'), signature))
+		})
+	}
+
+	sk, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	assert.NoError(t, err)
+	_, err = NewECDSASigner(sk)
+	assert.Error(t, err)
+}
@@ -0,0 +1,377 @@
+package jws
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/cacao"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-varint"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Verifier checks commit signatures against the DID documents of their signers.
+type Verifier struct {
+	resolver dids.DIDResolver
+	now      func() time.Time
+}
+
+func NewVerifier(resolver dids.DIDResolver) *Verifier {
+	return &Verifier{resolver: resolver, now: time.Now}
+}
+
+// VerifyJWS verifies the signature of a commit envelope and returns the DID that signed it.
+func (v *Verifier) VerifyJWS(envelope streams.DAGJWS) (string, error) {
+	signer, _, err := v.verifyJWS(envelope)
+	return signer, err
+}
+
+func (v *Verifier) verifyJWS(envelope streams.DAGJWS) (string, *Header, error) {
+	if len(envelope.Signatures) != 1 {
+		return "", nil, fmt.Errorf("expected one signature, got %d", len(envelope.Signatures))
+	}
+	signature := envelope.Signatures[0]
+	header, err := decodeHeader(signature.Protected)
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	_, link, err := cid.CidFromBytes(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("payload is not a CID: %w", err)
+	}
+	if envelope.Link != "" && envelope.Link != link.String() {
+		return "", nil, fmt.Errorf("payload<%s> does not match link<%s>", link, envelope.Link)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	did := strings.SplitN(header.KeyID, "#", 2)[0]
+	resolved, err := v.resolver.Resolve(did)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not resolve signer<%s>: %w", did, err)
+	}
+	method, err := findVerificationMethod(resolved.Document, header.KeyID)
+	if err != nil {
+		return "", nil, err
+	}
+	publicKey, err := methodPublicKey(*method)
+	if err != nil {
+		return "", nil, err
+	}
+	signingInput := []byte(signature.Protected + "." + envelope.Payload)
+	if err := verifySignature(header.Algorithm, publicKey, signingInput, sig); err != nil {
+		return "", nil, fmt.Errorf("invalid signature by key<%s>: %w", header.KeyID, err)
+	}
+	return did, header, nil
+}
+
+// VerifyCommits replays a stream log, checking that every signed commit carries a valid signature over its payload
+// by a DID that controlled the stream when the commit was applied. An unsigned genesis commit is allowed. A commit
+// signed with a session key counts as signed by the issuer of the CACAO it carries, if the CACAO grants the stream
+// and was valid when the commit was anchored. Anchor commits must carry their proof for their time to be known.
+func (v *Verifier) VerifyCommits(streamType streams.StreamType, commits []streams.CommitData) error {
+	if len(commits) == 0 {
+		return errors.New("no commits to verify")
+	}
+	anchoredAt := anchorTimes(commits)
+	state, err := streams.ApplyGenesis(streamType, commits[0])
+	if err != nil {
+		return err
+	}
+	if err := v.verifyCommit(commits[0], *state, anchoredAt[0]); err != nil {
+		return err
+	}
+	for i, commit := range commits[1:] {
+		if commit.Type != streams.AnchorCommitType {
+			if err := v.verifyCommit(commit, *state, anchoredAt[i+1]); err != nil {
+				return err
+			}
+		}
+		if state, err = streams.ApplyCommit(*state, commit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anchorTimes returns, for each commit, the time of the first anchor commit after it with a known block timestamp,
+// or the zero time if it has not been anchored.
+func anchorTimes(commits []streams.CommitData) []time.Time {
+	times := make([]time.Time, len(commits))
+	var next time.Time
+	for i := len(commits) - 1; i >= 0; i-- {
+		times[i] = next
+		if commits[i].Type == streams.AnchorCommitType && commits[i].Proof.BlockTimestamp > 0 {
+			next = time.Unix(int64(commits[i].Proof.BlockTimestamp), 0)
+		}
+	}
+	return times
+}
+
+// verifyCommit checks a commit against the state of the stream before it is applied, or the state the genesis
+// commit creates. anchoredAt is the time the commit was anchored, if it was.
+func (v *Verifier) verifyCommit(commit streams.CommitData, state streams.StreamState, anchoredAt time.Time) error {
+	if len(commit.Envelope.Signatures) == 0 {
+		if commit.Type == streams.GenesisCommitType {
+			return nil
+		}
+		return fmt.Errorf("commit<%s> is not signed", commit.CID)
+	}
+	signer, header, err := v.verifyJWS(commit.Envelope)
+	if err != nil {
+		return fmt.Errorf("commit<%s>: %w", commit.CID, err)
+	}
+	if err := checkPayload(commit); err != nil {
+		return err
+	}
+	if header.Capability != "" {
+		checkTime := anchoredAt
+		if checkTime.IsZero() {
+			checkTime = v.now()
+		}
+		if signer, err = checkCapability(commit, header.Capability, signer, state, checkTime); err != nil {
+			return fmt.Errorf("commit<%s>: %w", commit.CID, err)
+		}
+	}
+	for _, controller := range currentControllers(state) {
+		if controller == signer {
+			return nil
+		}
+	}
+	return fmt.Errorf("commit<%s> is signed by<%s>, which is not a controller of the stream", commit.CID, signer)
+}
+
+// checkPayload makes sure the signed link is the CID of the commit's linked block, and that the payload was decoded
+// from that block. The CID is computed over the block as received, since the decoded payload does not round trip.
+func checkPayload(commit streams.CommitData) error {
+	if commit.Commit == nil || len(commit.LinkedBlock) == 0 {
+		return fmt.Errorf("commit<%s> has no payload", commit.CID)
+	}
+	payloadCID, err := dagcbor.CID(commit.LinkedBlock)
+	if err != nil {
+		return err
+	}
+	if payloadCID.String() != commit.Envelope.Link {
+		return fmt.Errorf("signature of commit<%s> does not cover its payload", commit.CID)
+	}
+	payload, err := dagcbor.DecodeJSON(commit.LinkedBlock)
+	if err != nil {
+		return fmt.Errorf("could not decode payload of commit<%s>: %w", commit.CID, err)
+	}
+	if !bytes.Equal(payload, *commit.Commit) {
+		return fmt.Errorf("signature of commit<%s> does not cover its payload", commit.CID)
+	}
+	return nil
+}
+
+// checkCapability verifies the CACAO a session signed commit carries, as of the given time, and returns its issuer, on
+// whose behalf the session key signed.
+func checkCapability(commit streams.CommitData, capability, sessionDID string, state streams.StreamState, at time.Time) (string, error) {
+	if len(commit.CapabilityBlock) == 0 {
+		return "", fmt.Errorf("cacao<%s> is missing", capability)
+	}
+	link, err := dagcbor.CID(commit.CapabilityBlock)
+	if err != nil {
+		return "", err
+	}
+	if cacao.IPFSPrefix+link.String() != capability {
+		return "", fmt.Errorf("attached cacao<%s> is not<%s>", link, capability)
+	}
+	c, err := cacao.Decode(commit.CapabilityBlock)
+	if err != nil {
+		return "", err
+	}
+	if c.Payload.Audience != sessionDID {
+		return "", fmt.Errorf("cacao audience<%s> is not the signer<%s>", c.Payload.Audience, sessionDID)
+	}
+	if _, err := time.Parse(time.RFC3339, c.Payload.IssuedAt); err != nil {
+		return "", fmt.Errorf("invalid cacao issued at<%s>: %w", c.Payload.IssuedAt, err)
+	}
+	if commit.DisableTimeCheck {
+		err = c.VerifySignature()
+	} else {
+		err = c.Verify(at)
+	}
+	if err != nil {
+		return "", err
+	}
+	genesis, err := cid.Decode(state.Log[0].CID)
+	if err != nil {
+		return "", err
+	}
+	streamID := streams.NewStreamID(streams.StreamType(state.Type), genesis).String()
+	metadata := state.Metadata
+	if streams.HasPendingChanges(state) {
+		metadata = state.Next.Metadata
+	}
+	if !c.Allows(streamID, metadata.Family) {
+		return "", fmt.Errorf("stream<%s>: %w", streamID, ErrOutsideCapability)
+	}
+	return c.Payload.Issuer, nil
+}
+
+func currentControllers(state streams.StreamState) []string {
+	if streams.HasPendingChanges(state) {
+		return state.Next.Metadata.Controllers
+	}
+	return state.Metadata.Controllers
+}
+
+func decodeHeader(protected string) (*Header, error) {
+	headerBytes, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protected header: %w", err)
+	}
+	var header Header
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, fmt.Errorf("invalid protected header: %w", err)
+	}
+	if header.KeyID == "" {
+		return nil, errors.New("protected header has no kid")
+	}
+	return &header, nil
+}
+
+// findVerificationMethod looks for the key among the document's methods. Method IDs may be relative to the document.
+func findVerificationMethod(document dids.Document, keyID string) (*dids.VerificationMethod, error) {
+	methods := append(append([]dids.VerificationMethod{}, document.VerificationMethod...), document.Authentication...)
+	for i, method := range methods {
+		id := method.ID
+		if strings.HasPrefix(id, "#") {
+			id = document.ID + id
+		}
+		if id == keyID {
+			return &methods[i], nil
+		}
+	}
+	return nil, fmt.Errorf("key<%s> not found in document of<%s>", keyID, document.ID)
+}
+
+func methodPublicKey(method dids.VerificationMethod) (crypto.PublicKey, error) {
+	if method.Type == dids.JSONWebKey2020 {
+		if method.PublicKeyJwk == nil {
+			return nil, fmt.Errorf("key<%s> has no jwk", method.ID)
+		}
+		if method.PublicKeyJwk.Use == "enc" {
+			return nil, fmt.Errorf("key<%s> is an encryption key", method.ID)
+		}
+		_, key, err := method.PublicKeyJwk.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key<%s>: %w", method.ID, err)
+		}
+		return key, nil
+	}
+	_, keyBytes, err := multibase.Decode(method.PublicKeyMultibase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key<%s>: %w", method.ID, err)
+	}
+	switch method.Type {
+	case "Ed25519VerificationKey2018", "Ed25519VerificationKey2020":
+		if codec, n, err := varint.FromUvarint(keyBytes); err == nil && codec == uint64(multicodec.Ed25519Pub) &&
+			len(keyBytes)-n == ed25519.PublicKeySize {
+			keyBytes = keyBytes[n:]
+		}
+		if len(keyBytes) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid key<%s>: wrong ed25519 key length<%d>", method.ID, len(keyBytes))
+		}
+		return ed25519.PublicKey(keyBytes), nil
+	case dids.Secp256k1VerificationKey2019, "Secp256k1VerificationKey2018":
+		if codec, n, err := varint.FromUvarint(keyBytes); err == nil && codec == uint64(multicodec.Secp256k1Pub) &&
+			len(keyBytes)-n == btcec.PubKeyBytesLenCompressed {
+			keyBytes = keyBytes[n:]
+		}
+		key, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key<%s>: %w", method.ID, err)
+		}
+		return key, nil
+	case dids.P256Key2021, dids.P384Key2021:
+		curve, codec := elliptic.P256(), dids.P256MultiCodec
+		if method.Type == dids.P384Key2021 {
+			curve, codec = elliptic.P384(), dids.P384MultiCodec
+		}
+		if prefix := varint.ToUvarint(uint64(codec)); bytes.HasPrefix(keyBytes, prefix) {
+			keyBytes = keyBytes[len(prefix):]
+		}
+		x, y := elliptic.UnmarshalCompressed(curve, keyBytes)
+		if x == nil {
+			return nil, fmt.Errorf("invalid key<%s>: not a compressed %s point", method.ID, curve.Params().Name)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported verification method type<%s>", method.Type)
+	}
+}
+
+func verifySignature(alg string, publicKey crypto.PublicKey, signingInput, signature []byte) error {
+	switch alg {
+	case EdDSA:
+		key, ok := publicKey.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg<%s> does not match the key type", alg)
+		}
+		if !ed25519.Verify(key, signingInput, signature) {
+			return errors.New("signature does not verify")
+		}
+		return nil
+	case ES256K:
+		key, ok := publicKey.(*btcec.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg<%s> does not match the key type", alg)
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid %s signature length<%d>", alg, len(signature))
+		}
+		var r, s btcec.ModNScalar
+		if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) {
+			return errors.New("signature values overflow the curve order")
+		}
+		hash := sha256.Sum256(signingInput)
+		if !btcecdsa.NewSignature(&r, &s).Verify(hash[:], key) {
+			return errors.New("signature does not verify")
+		}
+		return nil
+	case ES256, ES384:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("alg<%s> does not match the key type", alg)
+		}
+		if keyAlg, err := curveAlgorithm(key.Curve); err != nil || keyAlg != alg {
+			return fmt.Errorf("alg<%s> does not match the key type", alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid %s signature length<%d>", alg, len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, hashFor(alg, signingInput), r, s) {
+			return errors.New("signature does not verify")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported alg<%s>", alg)
+	}
+}
@@ -0,0 +1,176 @@
+package jws
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/tile"
+	"github.com/stretchr/testify/assert"
+	"github.com/textileio/go-did-resolver/keys"
+	"testing"
+)
+
+func newTestSigner(t *testing.T) *Ed25519Signer {
+	_, sk, err := internal.GenerateEd25519Key()
+	assert.NoError(t, err)
+	signer, err := NewEd25519Signer(sk)
+	assert.NoError(t, err)
+	return signer
+}
+
+func loadCommits(t *testing.T, ceramic api.CeramicAPI, streamID string) []streams.CommitData {
+	resp, err := ceramic.GetCommits(api.GetCommitsRequest{StreamID: streamID})
+	assert.NoError(t, err)
+	var commits []streams.CommitData
+	for _, record := range resp.Commits {
+		commit, err := streams.DecodeCommit(record)
+		assert.NoError(t, err)
+		commits = append(commits, *commit)
+	}
+	return commits
+}
+
+// jwkSigner signs with an ECDSA key as the key's did:jwk.
+type jwkSigner struct {
+	*ECDSASigner
+	did string
+}
+
+func (s jwkSigner) DID() string {
+	return s.did
+}
+
+func (s jwkSigner) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	header := s.Header()
+	header.KeyID = s.did + "#0"
+	return signCommit(header, payload, s.Sign)
+}
+
+func TestVerifier(t *testing.T) {
+	verifier := NewVerifier(dids.CreateDIDResolver("", keys.New()))
+	owner, other := newTestSigner(t), newTestSigner(t)
+	ceramic := testutil.NewMemoryCeramic()
+	doc, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+		Controllers: []string{owner.DID()},
+	}, streams.DefaultCreateOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Update(map[string]string{"title": "second"}, owner, streams.DefaultUpdateOpts))
+
+	t.Run("verify jws", func(tt *testing.T) {
+		commits := loadCommits(tt, ceramic, doc.ID())
+		signer, err := verifier.VerifyJWS(commits[1].Envelope)
+		assert.NoError(tt, err)
+		assert.Equal(tt, owner.DID(), signer)
+
+		tampered := commits[1].Envelope
+		tampered.Signatures = []streams.JWSSignature{{Protected: tampered.Signatures[0].Protected, Signature: "c2ln"}}
+		_, err = verifier.VerifyJWS(tampered)
+		assert.Error(tt, err)
+
+		_, err = verifier.VerifyJWS(streams.DAGJWS{})
+		assert.Error(tt, err)
+	})
+
+	t.Run("signed by the controller", func(tt *testing.T) {
+		assert.NoError(tt, verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, doc.ID())))
+	})
+
+	t.Run("signature does not cover the payload", func(tt *testing.T) {
+		commits := loadCommits(tt, ceramic, doc.ID())
+		commits[1].Commit = commits[0].Commit
+		assert.Error(tt, verifier.VerifyCommits(streams.Tile, commits))
+	})
+
+	t.Run("payload with links and floats", func(tt *testing.T) {
+		linked, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{owner.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		genesis := linked.State().Log[0].CID
+		assert.NoError(tt, linked.Update(map[string]interface{}{
+			"ref":   map[string]string{"/": genesis},
+			"ratio": 1.5,
+		}, owner, streams.DefaultUpdateOpts))
+		assert.NoError(tt, verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, linked.ID())))
+	})
+
+	t.Run("signed by someone else", func(tt *testing.T) {
+		forged, err := tile.Load(ceramic, doc.ID(), streams.DefaultLoadOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, forged.Update(map[string]string{"title": "forged"}, other, streams.DefaultUpdateOpts))
+
+		err = verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, doc.ID()))
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "not a controller")
+	})
+
+	t.Run("controllers at each point of the log", func(tt *testing.T) {
+		transferred, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{owner.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		_, err = transferred.ChangeController(other.DID(), owner, streams.DefaultUpdateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, transferred.Update(map[string]string{"title": "new owner"}, other, streams.DefaultUpdateOpts))
+		assert.NoError(tt, verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, transferred.ID())))
+
+		assert.NoError(tt, transferred.Update(map[string]string{"title": "old owner"}, owner, streams.DefaultUpdateOpts))
+		assert.Error(tt, verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, transferred.ID())))
+	})
+
+	t.Run("secp256k1 controller", func(tt *testing.T) {
+		_, sk, err := internal.GenerateSecp256k1Key()
+		assert.NoError(tt, err)
+		signer, err := NewSecp256k1Signer(sk)
+		assert.NoError(tt, err)
+		secpDoc, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{signer.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, secpDoc.Update(map[string]string{"title": "secp256k1"}, signer, streams.DefaultUpdateOpts))
+
+		keyVerifier := NewVerifier(dids.CreateDIDResolver("", dids.NewKeyResolver()))
+		assert.NoError(tt, keyVerifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, secpDoc.ID())))
+	})
+
+	t.Run("P-256 controller", func(tt *testing.T) {
+		sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(tt, err)
+		signer, err := NewECDSASigner(sk)
+		assert.NoError(tt, err)
+		p256Doc, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{signer.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, p256Doc.Update(map[string]string{"title": "P-256"}, signer, streams.DefaultUpdateOpts))
+
+		keyVerifier := NewVerifier(dids.CreateDIDResolver("", dids.NewKeyResolver()))
+		assert.NoError(tt, keyVerifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, p256Doc.ID())))
+	})
+
+	t.Run("did:jwk controller", func(tt *testing.T) {
+		sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(tt, err)
+		keySigner, err := NewECDSASigner(sk)
+		assert.NoError(tt, err)
+		did, err := dids.CreateJWKDID(&sk.PublicKey)
+		assert.NoError(tt, err)
+		signer := jwkSigner{ECDSASigner: keySigner, did: *did}
+		jwkDoc, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{signer.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, jwkDoc.Update(map[string]string{"title": "did:jwk"}, signer, streams.DefaultUpdateOpts))
+
+		jwkVerifier := NewVerifier(dids.CreateDIDResolver("", dids.NewJWKResolver()))
+		assert.NoError(tt, jwkVerifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, jwkDoc.ID())))
+	})
+
+	t.Run("unsigned update", func(tt *testing.T) {
+		unsigned, err := tile.Create(ceramic, nil, streams.TileMetadataArgs{Controllers: []string{owner.DID()}}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		commit, err := streams.NewPatchCommit(unsigned.State(), map[string]string{"title": "unsigned"}, streams.PatchOpts{})
+		assert.NoError(tt, err)
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: unsigned.ID(), Commit: commit})
+		assert.NoError(tt, err)
+
+		err = verifier.VerifyCommits(streams.Tile, loadCommits(tt, ceramic, unsigned.ID()))
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "is not signed")
+	})
+}
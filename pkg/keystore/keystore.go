@@ -0,0 +1,422 @@
+package keystore
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/jws"
+	"golang.org/x/crypto/scrypt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	Version = 1
+
+	keySize   = 32
+	saltSize  = 16
+	checkData = "ceramic keystore"
+
+	// bounds on the scrypt parameters of a keystore, so that a crafted file cannot make opening it use more than
+	// maxScryptMemory bytes or run for minutes
+	maxScryptN      = 1 << 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30
+)
+
+var (
+	ErrWrongPassword = errors.New("wrong keystore password")
+	ErrKeyNotFound   = errors.New("key not found")
+
+	// DefaultScryptParams are the scrypt parameters for new keystores. Keystores record their parameters, so
+	// changing these does not affect existing files.
+	DefaultScryptParams = ScryptParams{N: 1 << 15, R: 8, P: 1}
+)
+
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// file is the on-disk format of a keystore. Every key is sealed with AES-256-GCM under a key derived from the
+// password with scrypt, with the key's DID as additional data so that sealed keys cannot be swapped between DIDs.
+type file struct {
+	Version int            `json:"version"`
+	Salt    []byte         `json:"salt"`
+	Scrypt  ScryptParams   `json:"scrypt"`
+	Check   sealed         `json:"check"`
+	Keys    []encryptedKey `json:"keys"`
+}
+
+type sealed struct {
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type encryptedKey struct {
+	DID  string       `json:"did"`
+	Type dids.KeyType `json:"type"`
+	sealed
+}
+
+// Keystore holds the private keys of controller DIDs in a password protected file. Changes are written to the file
+// immediately.
+type Keystore struct {
+	path string
+	aead cipher.AEAD
+
+	mu   sync.RWMutex
+	file file
+}
+
+// Create creates a new, empty keystore at path. It fails if the file exists.
+func Create(path, password string) (*Keystore, error) {
+	return CreateWithParams(path, password, DefaultScryptParams)
+}
+
+func CreateWithParams(path, password string, params ScryptParams) (*Keystore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("keystore<%s> already exists", path)
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := deriveAEAD(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	check, err := seal(aead, []byte(checkData), nil)
+	if err != nil {
+		return nil, err
+	}
+	k := &Keystore{
+		path: path,
+		aead: aead,
+		file: file{Version: Version, Salt: salt, Scrypt: params, Check: *check, Keys: []encryptedKey{}},
+	}
+	if err := k.save(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Open opens the keystore at path, returning ErrWrongPassword if password does not unlock it.
+func Open(path, password string) (*Keystore, error) {
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(fileBytes, &f); err != nil {
+		return nil, fmt.Errorf("invalid keystore<%s>: %w", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported keystore version<%d>", f.Version)
+	}
+	aead, err := deriveAEAD(password, f.Salt, f.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := open(aead, f.Check, nil); err != nil {
+		return nil, ErrWrongPassword
+	}
+	return &Keystore{path: path, aead: aead, file: f}, nil
+}
+
+// List returns the DIDs of the stored keys in order.
+func (k *Keystore) List() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	list := make([]string, 0, len(k.file.Keys))
+	for _, key := range k.file.Keys {
+		list = append(list, key.DID)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Import stores a private key and returns its did:key. The key may be an ed25519.PrivateKey, a *btcec.PrivateKey or
+// a P-256 or P-384 *ecdsa.PrivateKey.
+func (k *Keystore) Import(privateKey crypto.PrivateKey) (string, error) {
+	keyType, did, keyBytes, err := encodePrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := seal(k.aead, keyBytes, []byte(did))
+	if err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, key := range k.file.Keys {
+		if key.DID == did {
+			return did, nil
+		}
+	}
+	k.file.Keys = append(k.file.Keys, encryptedKey{DID: did, Type: keyType, sealed: *sealedKey})
+	if err := k.save(); err != nil {
+		k.file.Keys = k.file.Keys[:len(k.file.Keys)-1]
+		return "", err
+	}
+	return did, nil
+}
+
+// Export returns a keystore holding only the key of a DID, encrypted under password. Private keys never leave a
+// keystore unencrypted; write the export to a file and Open it with password to use the key elsewhere.
+func (k *Keystore) Export(did, password string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, keyBytes, err := k.open(did)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := deriveAEAD(password, salt, k.file.Scrypt)
+	if err != nil {
+		return nil, err
+	}
+	check, err := seal(aead, []byte(checkData), nil)
+	if err != nil {
+		return nil, err
+	}
+	sealedKey, err := seal(aead, keyBytes, []byte(did))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(file{
+		Version: Version,
+		Salt:    salt,
+		Scrypt:  k.file.Scrypt,
+		Check:   *check,
+		Keys:    []encryptedKey{{DID: did, Type: key.Type, sealed: *sealedKey}},
+	}, "", "  ")
+}
+
+// Delete removes the key of a DID.
+func (k *Keystore) Delete(did string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, key := range k.file.Keys {
+		if key.DID != did {
+			continue
+		}
+		keys := append(append([]encryptedKey{}, k.file.Keys[:i]...), k.file.Keys[i+1:]...)
+		previous := k.file.Keys
+		k.file.Keys = keys
+		if err := k.save(); err != nil {
+			k.file.Keys = previous
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("did<%s>: %w", did, ErrKeyNotFound)
+}
+
+// Signer returns a commit signer for a stored DID.
+func (k *Keystore) Signer(did string) (jws.Signer, error) {
+	privateKey, err := k.privateKey(did)
+	if err != nil {
+		return nil, err
+	}
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+		return jws.NewEd25519Signer(key)
+	case *btcec.PrivateKey:
+		return jws.NewSecp256k1Signer(key)
+	case *ecdsa.PrivateKey:
+		return jws.NewECDSASigner(key)
+	default:
+		return nil, fmt.Errorf("no signer for key<%s>", did)
+	}
+}
+
+func (k *Keystore) privateKey(did string) (crypto.PrivateKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, keyBytes, err := k.open(did)
+	if err != nil {
+		return nil, err
+	}
+	return decodePrivateKey(did, key.Type, keyBytes)
+}
+
+// open decrypts the key of a DID. The caller must hold k.mu.
+func (k *Keystore) open(did string) (*encryptedKey, []byte, error) {
+	for i, key := range k.file.Keys {
+		if key.DID != did {
+			continue
+		}
+		keyBytes, err := open(k.aead, key.sealed, []byte(did))
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not decrypt key<%s>: %w", did, err)
+		}
+		return &k.file.Keys[i], keyBytes, nil
+	}
+	return nil, nil, fmt.Errorf("did<%s>: %w", did, ErrKeyNotFound)
+}
+
+// save writes the keystore to a temporary file and moves it over the keystore, so a failed write leaves the old
+// file intact.
+func (k *Keystore) save() error {
+	fileBytes, err := json.MarshalIndent(k.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(k.path), filepath.Base(k.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(fileBytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), k.path)
+}
+
+// validate checks that the parameters are usable by scrypt and within the keystore's bounds.
+func (p ScryptParams) validate() error {
+	if p.N < 2 || p.N > maxScryptN || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("scrypt n<%d> must be a power of two no greater than %d", p.N, maxScryptN)
+	}
+	if p.R < 1 || p.R > maxScryptR || p.P < 1 || p.P > maxScryptP {
+		return fmt.Errorf("scrypt r<%d> and p<%d> must be between 1 and %d and %d", p.R, p.P, maxScryptR, maxScryptP)
+	}
+	if 128*p.N*p.R > maxScryptMemory {
+		return fmt.Errorf("scrypt n<%d> and r<%d> need more than %d bytes", p.N, p.R, maxScryptMemory)
+	}
+	return nil
+}
+
+func deriveAEAD(password string, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	if err := params.validate(); err != nil {
+		return nil, fmt.Errorf("invalid keystore parameters: %w", err)
+	}
+	key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("could not derive keystore key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) (*sealed, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &sealed{Nonce: nonce, Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData)}, nil
+}
+
+func open(aead cipher.AEAD, s sealed, additionalData []byte) ([]byte, error) {
+	if len(s.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	return aead.Open(nil, s.Nonce, s.Ciphertext, additionalData)
+}
+
+func encodePrivateKey(privateKey crypto.PrivateKey) (dids.KeyType, string, []byte, error) {
+	switch key := privateKey.(type) {
+	case ed25519.PrivateKey:
+		if len(key) != ed25519.PrivateKeySize {
+			return "", "", nil, fmt.Errorf("invalid ed25519 private key length<%d>", len(key))
+		}
+		did, err := dids.CreateDIDKey(key.Public().(ed25519.PublicKey))
+		if err != nil {
+			return "", "", nil, err
+		}
+		return dids.Ed25519KeyType, *did, key.Seed(), nil
+	case *btcec.PrivateKey:
+		did, err := dids.CreateSecp256k1DIDKey(key.PubKey())
+		if err != nil {
+			return "", "", nil, err
+		}
+		return dids.Secp256k1KeyType, *did, key.Serialize(), nil
+	case *ecdsa.PrivateKey:
+		did, err := dids.CreateECDSADIDKey(&key.PublicKey)
+		if err != nil {
+			return "", "", nil, err
+		}
+		keyType := dids.P256KeyType
+		if key.Curve == elliptic.P384() {
+			keyType = dids.P384KeyType
+		}
+		keyBytes := make([]byte, (key.Curve.Params().BitSize+7)/8)
+		key.D.FillBytes(keyBytes)
+		return keyType, *did, keyBytes, nil
+	default:
+		return "", "", nil, fmt.Errorf("unsupported private key type<%T>", privateKey)
+	}
+}
+
+// decodePrivateKey decodes a stored key, checking that it is a valid key of its type and that it belongs to did.
+func decodePrivateKey(did string, keyType dids.KeyType, keyBytes []byte) (crypto.PrivateKey, error) {
+	var privateKey crypto.PrivateKey
+	switch keyType {
+	case dids.Ed25519KeyType:
+		if len(keyBytes) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid ed25519 seed length<%d>", len(keyBytes))
+		}
+		privateKey = ed25519.NewKeyFromSeed(keyBytes)
+	case dids.Secp256k1KeyType:
+		var scalar btcec.ModNScalar
+		if len(keyBytes) != 32 || scalar.SetByteSlice(keyBytes) || scalar.IsZero() {
+			return nil, errors.New("invalid secp256k1 private key")
+		}
+		privateKey = btcec.PrivKeyFromScalar(&scalar)
+	case dids.P256KeyType, dids.P384KeyType:
+		curve := elliptic.P256()
+		if keyType == dids.P384KeyType {
+			curve = elliptic.P384()
+		}
+		params := curve.Params()
+		d := new(big.Int).SetBytes(keyBytes)
+		if len(keyBytes) != (params.BitSize+7)/8 || d.Sign() <= 0 || d.Cmp(params.N) >= 0 {
+			return nil, fmt.Errorf("invalid %s private key", params.Name)
+		}
+		x, y := curve.ScalarBaseMult(keyBytes)
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid %s private key", params.Name)
+		}
+		privateKey = &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+	default:
+		return nil, fmt.Errorf("unsupported key type<%s>", keyType)
+	}
+	_, keyDID, _, err := encodePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyDID != did {
+		return nil, fmt.Errorf("stored key of<%s> belongs to<%s>", did, keyDID)
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,202 @@
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/dids"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testParams = ScryptParams{N: 1 << 10, R: 8, P: 1}
+
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	ks, err := CreateWithParams(path, "correct horse", testParams)
+	assert.NoError(t, err)
+	assert.Empty(t, ks.List())
+
+	_, edKey, err := internal.GenerateEd25519Key()
+	assert.NoError(t, err)
+	_, secpKey, err := internal.GenerateSecp256k1Key()
+	assert.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	var imported []string
+	for _, key := range []interface{}{edKey, secpKey, p256Key} {
+		did, err := ks.Import(key)
+		assert.NoError(t, err)
+		imported = append(imported, did)
+	}
+
+	t.Run("file is encrypted", func(tt *testing.T) {
+		info, err := os.Stat(path)
+		assert.NoError(tt, err)
+		assert.Equal(tt, os.FileMode(0600), info.Mode().Perm())
+
+		fileBytes, err := ioutil.ReadFile(path)
+		assert.NoError(tt, err)
+		assert.Contains(tt, string(fileBytes), imported[0])
+		assert.NotContains(tt, string(fileBytes), string(edKey.Seed()))
+	})
+
+	t.Run("reopen", func(tt *testing.T) {
+		_, err := Open(path, "wrong")
+		assert.True(tt, errors.Is(err, ErrWrongPassword))
+
+		reopened, err := Open(path, "correct horse")
+		assert.NoError(tt, err)
+		assert.ElementsMatch(tt, imported, reopened.List())
+
+		privateKey, err := reopened.privateKey(imported[0])
+		assert.NoError(tt, err)
+		assert.Equal(tt, edKey, privateKey)
+		privateKey, err = reopened.privateKey(imported[1])
+		assert.NoError(tt, err)
+		assert.Equal(tt, secpKey.Serialize(), privateKey.(interface{ Serialize() []byte }).Serialize())
+		privateKey, err = reopened.privateKey(imported[2])
+		assert.NoError(tt, err)
+		assert.True(tt, p256Key.Equal(privateKey))
+	})
+
+	t.Run("signers", func(tt *testing.T) {
+		for _, did := range imported {
+			signer, err := ks.Signer(did)
+			assert.NoError(tt, err)
+			assert.Equal(tt, did, signer.DID())
+			_, err = signer.SignCommit(streams.RawCommit{})
+			assert.NoError(tt, err)
+		}
+	})
+
+	t.Run("import twice", func(tt *testing.T) {
+		did, err := ks.Import(edKey)
+		assert.NoError(tt, err)
+		assert.Equal(tt, imported[0], did)
+		assert.Len(tt, ks.List(), 3)
+
+		_, err = ks.Import("not a key")
+		assert.Error(tt, err)
+	})
+
+	t.Run("delete", func(tt *testing.T) {
+		assert.NoError(tt, ks.Delete(imported[1]))
+		_, err := ks.Signer(imported[1])
+		assert.True(tt, errors.Is(err, ErrKeyNotFound))
+		assert.True(tt, errors.Is(ks.Delete(imported[1]), ErrKeyNotFound))
+
+		reopened, err := Open(path, "correct horse")
+		assert.NoError(tt, err)
+		assert.Len(tt, reopened.List(), 2)
+	})
+
+	t.Run("existing file", func(tt *testing.T) {
+		_, err := Create(path, "other")
+		assert.Error(tt, err)
+	})
+
+	t.Run("tampered key", func(tt *testing.T) {
+		fileBytes, err := ioutil.ReadFile(path)
+		assert.NoError(tt, err)
+		// sealed keys are bound to their DID
+		swapped := strings.Replace(string(fileBytes), imported[0], "did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8", 1)
+		tampered := filepath.Join(dir, "tampered.json")
+		assert.NoError(tt, ioutil.WriteFile(tampered, []byte(swapped), 0600))
+
+		ks, err := Open(tampered, "correct horse")
+		assert.NoError(tt, err)
+		_, err = ks.Signer("did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8")
+		assert.Error(tt, err)
+	})
+
+	t.Run("export", func(tt *testing.T) {
+		exported, err := ks.Export(imported[0], "battery staple")
+		assert.NoError(tt, err)
+		assert.NotContains(tt, string(exported), string(edKey.Seed()))
+		exportPath := filepath.Join(dir, "export.json")
+		assert.NoError(tt, ioutil.WriteFile(exportPath, exported, 0600))
+
+		_, err = Open(exportPath, "correct horse")
+		assert.True(tt, errors.Is(err, ErrWrongPassword))
+		opened, err := Open(exportPath, "battery staple")
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{imported[0]}, opened.List())
+		signer, err := opened.Signer(imported[0])
+		assert.NoError(tt, err)
+		assert.Equal(tt, imported[0], signer.DID())
+
+		_, err = ks.Export("did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8", "battery staple")
+		assert.True(tt, errors.Is(err, ErrKeyNotFound))
+	})
+}
+
+func TestScryptParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, DefaultScryptParams.validate())
+	for _, params := range []ScryptParams{
+		{N: 0, R: 8, P: 1},
+		{N: 1000, R: 8, P: 1},
+		{N: 1 << 30, R: 8, P: 1},
+		{N: 1 << 20, R: 32, P: 1},
+		{N: 1 << 10, R: 0, P: 1},
+		{N: 1 << 10, R: 8, P: 1 << 20},
+	} {
+		assert.Error(t, params.validate(), "%+v", params)
+	}
+
+	// a file asking for more memory than allowed is refused before deriving the key
+	path := filepath.Join(dir, "keys.json")
+	_, err = CreateWithParams(path, "password", testParams)
+	assert.NoError(t, err)
+	fileBytes, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	crafted := strings.Replace(string(fileBytes), `"n": 1024`, `"n": 1073741824`, 1)
+	assert.NotEqual(t, string(fileBytes), crafted)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(crafted), 0600))
+	_, err = Open(path, "password")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid keystore parameters")
+}
+
+func TestDecodePrivateKey(t *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keyType, did, keyBytes, err := encodePrivateKey(p256Key)
+	assert.NoError(t, err)
+	decoded, err := decodePrivateKey(did, keyType, keyBytes)
+	assert.NoError(t, err)
+	assert.True(t, p256Key.Equal(decoded))
+
+	_, err = decodePrivateKey(did, keyType, make([]byte, 32))
+	assert.Error(t, err)
+	_, err = decodePrivateKey(did, keyType, elliptic.P256().Params().N.Bytes())
+	assert.Error(t, err)
+	_, err = decodePrivateKey(did, keyType, keyBytes[1:])
+	assert.Error(t, err)
+	_, err = decodePrivateKey(did, dids.Secp256k1KeyType, make([]byte, 32))
+	assert.Error(t, err)
+
+	// a valid scalar of another key
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, _, otherBytes, err := encodePrivateKey(other)
+	assert.NoError(t, err)
+	_, err = decodePrivateKey(did, keyType, otherBytes)
+	assert.Error(t, err)
+}
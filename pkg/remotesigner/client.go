@@ -0,0 +1,161 @@
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/jws"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrDenied is returned when the daemon refuses to sign, because the key has no policy or its policy does not allow
+// the commit.
+var ErrDenied = errors.New("remote signer denied the request")
+
+// Signer is a jws.Signer whose key is held by a signing daemon.
+type Signer struct {
+	did     string
+	baseURL string
+	token   string
+	*http.Client
+}
+
+// NewSigner creates a signer for did using the daemon listening at baseURL, such as http://127.0.0.1:7000, and
+// authenticating with the daemon's client token.
+func NewSigner(baseURL, token, did string) *Signer {
+	return &Signer{
+		did:     did,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		Client: &http.Client{
+			Timeout: time.Second * 5,
+		},
+	}
+}
+
+// NewUnixSigner creates a signer for did using the daemon listening on the unix socket at socketPath.
+func NewUnixSigner(socketPath, token, did string) *Signer {
+	signer := NewSigner("http://remotesigner", token, did)
+	signer.Client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return signer
+}
+
+func (s Signer) DID() string {
+	return s.did
+}
+
+// Keys lists the DIDs the daemon is willing to sign for.
+func (s Signer) Keys() ([]string, error) {
+	httpReq, err := http.NewRequest(http.MethodGet, s.baseURL+KeysPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set(authorizationHeader, bearerPrefix+s.token)
+
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not list remote signer keys: status %d", resp.StatusCode)
+	}
+	var data KeysResponse
+	if err := json.Unmarshal(respBytes, &data); err != nil {
+		return nil, err
+	}
+	return data.DIDs, nil
+}
+
+func (s Signer) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	// encoding locally rejects bad payloads early and gives the block the daemon must sign
+	block, err := jws.EncodeCommit(payload)
+	if err != nil {
+		return nil, err
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	reqBytes, err := json.Marshal(SignRequest{DID: s.did, Payload: payloadBytes})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.baseURL+SignPath, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(authorizationHeader, bearerPrefix+s.token)
+
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var data SignResponse
+	if resp.StatusCode != http.StatusOK {
+		// error bodies are SignResponses when they come from the daemon, anything else is reported as is
+		message := strings.TrimSpace(string(respBytes))
+		if err := json.Unmarshal(respBytes, &data); err == nil && data.Error != "" {
+			message = data.Error
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("%w: %s", ErrDenied, message)
+		}
+		return nil, fmt.Errorf("remote signer failed with status %d: %s", resp.StatusCode, message)
+	}
+	if err := json.Unmarshal(respBytes, &data); err != nil {
+		return nil, fmt.Errorf("could not decode remote signer response: %w", err)
+	}
+	if data.Commit == nil {
+		return nil, errors.New("remote signer returned no commit")
+	}
+	if err := checkSignedBlock(*data.Commit, block); err != nil {
+		return nil, err
+	}
+	return data.Commit, nil
+}
+
+// checkSignedBlock makes sure the daemon signed the commit that was asked for.
+func checkSignedBlock(commit streams.SignedCommit, block []byte) error {
+	link, err := dagcbor.CID(block)
+	if err != nil {
+		return err
+	}
+	linkedBlock, err := base64.StdEncoding.DecodeString(commit.LinkedBlock)
+	if err != nil {
+		return fmt.Errorf("could not decode signed block: %w", err)
+	}
+	if commit.JWS.Link != link.String() || !bytes.Equal(linkedBlock, block) {
+		return fmt.Errorf("remote signer signed commit<%s>, expected<%s>", commit.JWS.Link, link)
+	}
+	return nil
+}
+
+var _ jws.Signer = (*Signer)(nil)
@@ -0,0 +1,45 @@
+package remotesigner
+
+import (
+	"encoding/json"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"time"
+)
+
+// The remote signer protocol is JSON over HTTP, served on a unix socket or a TCP address. POST SignPath with a
+// SignRequest returns a SignResponse, and GET KeysPath returns a KeysResponse listing the DIDs the daemon signs for.
+// Every request carries the daemon's client token as a bearer token.
+
+const (
+	SignPath = "/v0/sign"
+	KeysPath = "/v0/keys"
+
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+type SignRequest struct {
+	DID     string          `json:"did"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type SignResponse struct {
+	Commit *streams.SignedCommit `json:"commit,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
+
+type KeysResponse struct {
+	DIDs []string `json:"dids"`
+}
+
+// AuditEntry records a single signing request and its outcome. The daemon writes one JSON encoded entry per line.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Remote   string    `json:"remote,omitempty"`
+	DID      string    `json:"did,omitempty"`
+	StreamID string    `json:"streamId,omitempty"`
+	Family   string    `json:"family,omitempty"`
+	Commit   string    `json:"commit,omitempty"`
+	Allowed  bool      `json:"allowed"`
+	Reason   string    `json:"reason,omitempty"`
+}
@@ -0,0 +1,243 @@
+package remotesigner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/jws"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/keystore"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/tile"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testGenesisCID = "bafyreihtdxfb6cpcvomm2c2elm3re2onqaix6frq4nbg45eaqszh5mifre"
+	testToken      = "client token"
+)
+
+func newTestKeystore(t *testing.T, dir string) (*keystore.Keystore, []string) {
+	ks, err := keystore.CreateWithParams(filepath.Join(dir, "keys.json"), "password", keystore.ScryptParams{N: 1 << 10, R: 8, P: 1})
+	assert.NoError(t, err)
+	var keys []string
+	for i := 0; i < 2; i++ {
+		_, privateKey, err := internal.GenerateEd25519Key()
+		assert.NoError(t, err)
+		did, err := ks.Import(privateKey)
+		assert.NoError(t, err)
+		keys = append(keys, did)
+	}
+	return ks, keys
+}
+
+func readAudit(t *testing.T, log *bytes.Buffer) []AuditEntry {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(bytes.NewReader(log.Bytes()))
+	for scanner.Scan() {
+		var entry AuditEntry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remotesigner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks, keys := newTestKeystore(t, dir)
+	ceramic := testutil.NewMemoryCeramic()
+	var auditLog bytes.Buffer
+	server, err := NewServer(Config{
+		Keys:     ks,
+		Token:    testToken,
+		Policies: map[string]Policy{keys[0]: {Families: []string{"notes"}}},
+		AuditLog: &auditLog,
+		Ceramic:  ceramic,
+	})
+	assert.NoError(t, err)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	signer := NewSigner(httpServer.URL, testToken, keys[0])
+
+	t.Run("keys", func(tt *testing.T) {
+		dids, err := signer.Keys()
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{keys[0]}, dids)
+	})
+
+	t.Run("allowed family", func(tt *testing.T) {
+		doc, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{keys[0]},
+			Family:      "notes",
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		assert.NoError(tt, doc.Update(map[string]string{"title": "second"}, signer, models.UpdateOpts{}))
+		assert.JSONEq(tt, `{"title":"second"}`, string(*doc.Content().(*json.RawMessage)))
+
+		entries := readAudit(tt, &auditLog)
+		last := entries[len(entries)-1]
+		assert.True(tt, last.Allowed)
+		assert.Equal(tt, keys[0], last.DID)
+		assert.Equal(tt, doc.ID(), last.StreamID)
+		assert.Equal(tt, "notes", last.Family)
+		assert.NotEmpty(tt, last.Commit)
+	})
+
+	t.Run("denied family", func(tt *testing.T) {
+		doc, err := tile.Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+			Controllers: []string{keys[0]},
+			Family:      "secrets",
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		err = doc.Update(map[string]string{"title": "second"}, signer, models.UpdateOpts{})
+		assert.True(tt, errors.Is(err, ErrDenied))
+
+		// moving a stream into an allowed family is not enough
+		_, err = signer.SignCommit(streams.RawCommit{ID: doc.State().Log[0].CID, Prev: doc.Tip(), Header: streams.CommitHeader{Family: "notes"}})
+		assert.True(tt, errors.Is(err, ErrDenied))
+
+		_, err = signer.SignCommit(streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+			Controllers: []string{keys[0]},
+			Family:      "secrets",
+		}}})
+		assert.True(tt, errors.Is(err, ErrDenied))
+
+		entries := readAudit(tt, &auditLog)
+		last := entries[len(entries)-1]
+		assert.False(tt, last.Allowed)
+		assert.Equal(tt, "secrets", last.Family)
+		assert.Contains(tt, last.Reason, "family<secrets>")
+	})
+
+	t.Run("key without policy", func(tt *testing.T) {
+		_, err := NewSigner(httpServer.URL, testToken, keys[1]).SignCommit(streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+			Controllers: []string{keys[1]},
+			Family:      "notes",
+		}}})
+		assert.True(tt, errors.Is(err, ErrDenied))
+	})
+
+	t.Run("every request is audited", func(tt *testing.T) {
+		entries := readAudit(tt, &auditLog)
+		assert.Len(tt, entries, 5)
+		assert.Equal(tt, keys[1], entries[4].DID)
+	})
+
+	t.Run("unix socket", func(tt *testing.T) {
+		socket := filepath.Join(dir, "signer.sock")
+		listener, err := ListenUnix(socket)
+		assert.NoError(tt, err)
+		defer listener.Close()
+		go server.Serve(listener)
+
+		info, err := os.Stat(socket)
+		assert.NoError(tt, err)
+		assert.Equal(tt, os.FileMode(0600), info.Mode().Perm())
+
+		dids, err := NewUnixSigner(socket, testToken, keys[0]).Keys()
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{keys[0]}, dids)
+
+		_, err = NewUnixSigner(socket, "", keys[0]).Keys()
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 401")
+
+		shared := filepath.Join(dir, "shared")
+		assert.NoError(tt, os.Mkdir(shared, 0755))
+		_, err = ListenUnix(filepath.Join(shared, "signer.sock"))
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "accessible by other users")
+	})
+
+	t.Run("unauthenticated", func(tt *testing.T) {
+		before := len(readAudit(tt, &auditLog))
+		for _, token := range []string{"", "wrong token"} {
+			unauthenticated := NewSigner(httpServer.URL, token, keys[0])
+			_, err := unauthenticated.Keys()
+			assert.Error(tt, err)
+			assert.Contains(tt, err.Error(), "status 401")
+			_, err = unauthenticated.SignCommit(streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+				Controllers: []string{keys[0]},
+				Family:      "notes",
+			}}})
+			assert.Error(tt, err)
+			assert.Contains(tt, err.Error(), "missing or wrong client token")
+		}
+		assert.Len(tt, readAudit(tt, &auditLog), before)
+
+		_, err := NewServer(Config{Keys: ks, AuditLog: &auditLog})
+		assert.Error(tt, err)
+	})
+
+	t.Run("payload without stream or genesis header", func(tt *testing.T) {
+		_, err := signer.SignCommit(map[string]string{"anything": "at all"})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 400")
+		assert.False(tt, errors.Is(err, ErrDenied))
+	})
+
+	t.Run("stream type is looked up", func(tt *testing.T) {
+		resp, err := ceramic.CreateStream(api.CreateStreamRequest{
+			Type: int(streams.CAIP10Link),
+			Genesis: streams.GenesisCommit{Header: streams.GenesisHeader{CommitHeader: streams.CommitHeader{
+				Controllers: []string{keys[0]},
+				Family:      "secrets",
+			}}},
+		})
+		assert.NoError(tt, err)
+		state, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: resp.Response.ID})
+		assert.NoError(tt, err)
+
+		_, err = signer.SignCommit(streams.RawCommit{ID: state.Response.Log[0].CID, Prev: state.Response.Log[0].CID})
+		assert.True(tt, errors.Is(err, ErrDenied))
+		entries := readAudit(tt, &auditLog)
+		assert.Equal(tt, resp.Response.ID, entries[len(entries)-1].StreamID)
+
+		_, err = signer.SignCommit(streams.RawCommit{ID: testGenesisCID, Prev: testGenesisCID})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "no stream starts with genesis")
+	})
+}
+
+func TestSignerChecksCommit(t *testing.T) {
+	_, privateKey, err := internal.GenerateEd25519Key()
+	assert.NoError(t, err)
+	local, err := jws.NewEd25519Signer(privateKey)
+	assert.NoError(t, err)
+
+	// a daemon that signs something other than what it was asked to
+	other, err := local.SignCommit(map[string]string{"other": "commit"})
+	assert.NoError(t, err)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, SignResponse{Commit: other})
+	}))
+	defer httpServer.Close()
+
+	_, err = NewSigner(httpServer.URL, testToken, local.DID()).SignCommit(map[string]string{"asked": "for"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "remote signer signed commit")
+}
+
+func TestSignerChecksStatus(t *testing.T) {
+	// a base URL that does not point at the daemon
+	httpServer := httptest.NewServer(http.NotFoundHandler())
+	defer httpServer.Close()
+
+	_, err := NewSigner(httpServer.URL, testToken, "did:key:z6Mk").SignCommit(map[string]string{"asked": "for"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404: 404 page not found")
+}
@@ -0,0 +1,264 @@
+package remotesigner
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/jws"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxRequestSize = 1 << 20
+
+// KeySource holds the keys the daemon signs with. A keystore.Keystore is a KeySource.
+type KeySource interface {
+	List() []string
+	Signer(did string) (jws.Signer, error)
+}
+
+// Policy limits what a key may sign.
+type Policy struct {
+	// Families are the stream families the key may sign commits for. An empty list allows every family.
+	Families []string `json:"families,omitempty"`
+}
+
+func (p Policy) allows(family string) bool {
+	if len(p.Families) == 0 {
+		return true
+	}
+	for _, allowed := range p.Families {
+		if allowed == family {
+			return true
+		}
+	}
+	return false
+}
+
+type Config struct {
+	Keys KeySource
+	// Token is the secret clients must send as a bearer token. Every request without it is refused, whatever the
+	// listener it arrives on.
+	Token string
+	// Policies maps a DID to the policy of its key. Keys without a policy cannot sign.
+	Policies map[string]Policy
+	// AuditLog receives an AuditEntry for every signing request. A request is refused if its entry cannot be written.
+	AuditLog io.Writer
+	// Ceramic is used to look up the family of the stream an update commit belongs to. Without it, keys limited to
+	// some families can only sign genesis commits.
+	Ceramic api.CeramicAPI
+}
+
+// Server is the signing daemon. It is an http.Handler serving the remote signer protocol.
+type Server struct {
+	token    string
+	keys     KeySource
+	policies map[string]Policy
+	ceramic  api.CeramicAPI
+
+	auditMu sync.Mutex
+	audit   *json.Encoder
+}
+
+func NewServer(config Config) (*Server, error) {
+	if config.Keys == nil {
+		return nil, errors.New("a key source is required")
+	}
+	if config.AuditLog == nil {
+		return nil, errors.New("an audit log is required")
+	}
+	if config.Token == "" {
+		return nil, errors.New("a client token is required")
+	}
+	return &Server{
+		token:    config.Token,
+		keys:     config.Keys,
+		policies: config.Policies,
+		ceramic:  config.Ceramic,
+		audit:    json.NewEncoder(config.AuditLog),
+	}, nil
+}
+
+// ListenUnix listens on a unix socket that only the current user can connect to, replacing a stale socket file. The
+// socket must be in a directory other users cannot access, so that none can connect before its mode is set.
+func ListenUnix(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("directory<%s> of socket is accessible by other users", dir)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve serves the remote signer protocol on listener until it is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	return http.Serve(listener, s)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, SignResponse{Error: "missing or wrong client token"})
+		return
+	}
+	switch {
+	case r.URL.Path == SignPath && r.Method == http.MethodPost:
+		s.handleSign(w, r)
+	case r.URL.Path == KeysPath && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, KeysResponse{DIDs: s.signableKeys()})
+	case r.URL.Path == SignPath || r.URL.Path == KeysPath:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get(authorizationHeader), bearerPrefix)
+	if token == r.Header.Get(authorizationHeader) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) signableKeys() []string {
+	var keys []string
+	for _, did := range s.keys.List() {
+		if _, ok := s.policies[did]; ok {
+			keys = append(keys, did)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	entry := AuditEntry{Time: time.Now().UTC(), Remote: r.RemoteAddr}
+	status, commit, err := s.sign(r, &entry)
+	entry.Allowed = err == nil
+	if err != nil {
+		entry.Reason = err.Error()
+	} else {
+		entry.Commit = commit.JWS.Link
+	}
+
+	// nothing is signed without a record of it
+	if auditErr := s.writeAudit(entry); auditErr != nil {
+		writeJSON(w, http.StatusInternalServerError, SignResponse{Error: fmt.Sprintf("could not write audit log: %s", auditErr)})
+		return
+	}
+	if err != nil {
+		writeJSON(w, status, SignResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, SignResponse{Commit: commit})
+}
+
+func (s *Server) sign(r *http.Request, entry *AuditEntry) (int, *streams.SignedCommit, error) {
+	var req SignRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		return http.StatusBadRequest, nil, fmt.Errorf("could not decode sign request: %w", err)
+	}
+	entry.DID = req.DID
+
+	policy, ok := s.policies[req.DID]
+	if !ok {
+		return http.StatusForbidden, nil, fmt.Errorf("key<%s> has no signing policy", req.DID)
+	}
+	var payload streams.RawCommit
+	if err := json.Unmarshal(req.Payload, &payload); err != nil {
+		return http.StatusBadRequest, nil, fmt.Errorf("could not decode commit payload: %w", err)
+	}
+	families, err := s.commitFamilies(payload, entry)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	for _, family := range families {
+		if !policy.allows(family) {
+			return http.StatusForbidden, nil, fmt.Errorf("key<%s> may not sign for family<%s>", req.DID, family)
+		}
+	}
+
+	signer, err := s.keys.Signer(req.DID)
+	if err != nil {
+		return http.StatusNotFound, nil, err
+	}
+	commit, err := signer.SignCommit(req.Payload)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	return http.StatusOK, commit, nil
+}
+
+// commitFamilies returns the families a commit touches: the family of the stream it belongs to, and the family it
+// sets in its header, if that differs.
+func (s *Server) commitFamilies(payload streams.RawCommit, entry *AuditEntry) ([]string, error) {
+	entry.Family = payload.Header.Family
+	if payload.ID == "" {
+		// a genesis commit names its own family, anything else must belong to a stream
+		if len(payload.Header.Controllers) == 0 {
+			return nil, errors.New("commit payload has neither a stream id nor a genesis header with controllers")
+		}
+		return []string{payload.Header.Family}, nil
+	}
+
+	genesis, err := cid.Decode(payload.ID)
+	if err != nil {
+		return nil, fmt.Errorf("commit id<%s> is not a CID: %w", payload.ID, err)
+	}
+	if s.ceramic == nil {
+		return nil, fmt.Errorf("cannot look up the stream of genesis<%s> without a ceramic node", genesis)
+	}
+	streamID, state, err := api.LoadGenesisStream(s.ceramic, genesis)
+	if err != nil {
+		return nil, err
+	}
+	entry.StreamID = streamID
+	metadata := state.Metadata
+	if streams.HasPendingChanges(*state) {
+		metadata = state.Next.Metadata
+	}
+	entry.Family = metadata.Family
+	families := []string{metadata.Family}
+	if payload.Header.Family != "" && payload.Header.Family != metadata.Family {
+		families = append(families, payload.Header.Family)
+	}
+	return families, nil
+}
+
+func (s *Server) writeAudit(entry AuditEntry) error {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+	return s.audit.Encode(entry)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+)
+
+// ValidatingClient wraps a CeramicAPI and validates content against its schema before creating streams or applying
+// commits, so schema violations surface before anything is sent to the node.
+type ValidatingClient struct {
+	api.CeramicAPI
+	*Validator
+}
+
+func NewValidatingClient(ceramic api.CeramicAPI) *ValidatingClient {
+	return &ValidatingClient{
+		CeramicAPI: ceramic,
+		Validator:  NewValidator(ceramic),
+	}
+}
+
+func (c ValidatingClient) CreateStream(req api.CreateStreamRequest) (*api.CreateStreamResponse, error) {
+	if err := c.ValidateCreateStream(req); err != nil {
+		return nil, err
+	}
+	return c.CeramicAPI.CreateStream(req)
+}
+
+func (c ValidatingClient) ApplyCommit(req api.ApplyCommitRequest) (*api.ApplyCommitResponse, error) {
+	if err := c.ValidateApplyCommit(req); err != nil {
+		return nil, err
+	}
+	return c.CeramicAPI.ApplyCommit(req)
+}
+
+var _ api.CeramicAPI = (*ValidatingClient)(nil)
@@ -0,0 +1,254 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	schemaURLPrefix = "ceramic://"
+	// SchemaTTL is how long a schema named by a stream ID is used before it is loaded again. Schemas pinned to a
+	// commit ID cannot change and are kept for the life of the Validator.
+	SchemaTTL = time.Minute
+)
+
+type FieldError struct {
+	// Path JSON pointer to the offending value within the content, empty for the content itself
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Schema string       `json:"schema"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		path := field.Path
+		if path == "" {
+			path = "/"
+		}
+		fields = append(fields, fmt.Sprintf("%s: %s", path, field.Message))
+	}
+	return fmt.Sprintf("content does not conform to schema<%s>: %s", e.Schema, strings.Join(fields, "; "))
+}
+
+// Validator checks tile content against the JSON Schema named by the tile's metadata. Schemas are loaded from the
+// schema stream through the Ceramic API and compiled once per schema commit, or once per SchemaTTL for schemas named
+// by a mutable stream ID.
+type Validator struct {
+	ceramic api.CeramicAPI
+	now     func() time.Time
+	mu      sync.RWMutex
+	schemas map[string]cachedSchema
+}
+
+type cachedSchema struct {
+	schema *jsonschema.Schema
+	// expires is zero for schemas pinned to a commit
+	expires time.Time
+}
+
+func NewValidator(ceramic api.CeramicAPI) *Validator {
+	return &Validator{
+		ceramic: ceramic,
+		now:     time.Now,
+		schemas: make(map[string]cachedSchema),
+	}
+}
+
+// Validate checks content against the schema at schemaID, returning a *ValidationError listing every failing field.
+// An empty schemaID means the tile has no schema and any content is valid.
+func (v *Validator) Validate(schemaID string, content interface{}) error {
+	if schemaID == "" {
+		return nil
+	}
+	compiled, err := v.getSchema(schemaID)
+	if err != nil {
+		return err
+	}
+	doc, err := toJSONValue(content)
+	if err != nil {
+		return err
+	}
+	if err := compiled.Validate(doc); err != nil {
+		validationErr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return err
+		}
+		return &ValidationError{Schema: schemaID, Fields: fieldErrors(validationErr)}
+	}
+	return nil
+}
+
+// ValidateCreateStream checks the content of a genesis commit against the schema in its header.
+func (v *Validator) ValidateCreateStream(req api.CreateStreamRequest) error {
+	commit, err := decodeCommit(req.Genesis)
+	if err != nil {
+		return err
+	}
+	var genesis streams.GenesisCommit
+	if err := json.Unmarshal(*commit.Commit, &genesis); err != nil {
+		return fmt.Errorf("could not decode genesis: %w", err)
+	}
+	if genesis.Data == nil {
+		return nil
+	}
+	return v.Validate(genesis.Header.Schema, genesis.Data)
+}
+
+// ValidateApplyCommit checks the content an update commit would produce against the stream's schema, or the schema
+// the commit switches to. Anchor commits carry no content and always pass.
+func (v *Validator) ValidateApplyCommit(req api.ApplyCommitRequest) error {
+	commit, err := decodeCommit(req.Commit)
+	if err != nil {
+		return err
+	}
+	if commit.Type != streams.SignedCommitType {
+		return nil
+	}
+	var update streams.RawCommit
+	if err := json.Unmarshal(*commit.Commit, &update); err != nil {
+		return fmt.Errorf("could not decode commit: %w", err)
+	}
+
+	stateResp, err := v.ceramic.GetStreamState(api.StreamStateRequest{StreamID: req.StreamID})
+	if err != nil {
+		return fmt.Errorf("could not load stream<%s>: %w", req.StreamID, err)
+	}
+	if stateResp.ResponseCode != http.StatusOK {
+		return fmt.Errorf("could not load stream<%s>: status %d", req.StreamID, stateResp.ResponseCode)
+	}
+	state := stateResp.Response
+	content, schemaID := state.Content, state.Metadata.Schema
+	if streams.HasPendingChanges(state) {
+		schemaID = state.Next.Metadata.Schema
+		// a pending change of metadata only leaves the content as it is
+		if state.Next.Content != nil {
+			content = state.Next.Content
+		}
+	}
+	if update.Header.Schema != "" {
+		schemaID = update.Header.Schema
+	}
+	if schemaID == "" {
+		return nil
+	}
+	if update.Data != nil {
+		var ops []streams.PatchOperation
+		if err := json.Unmarshal(*update.Data, &ops); err != nil {
+			return fmt.Errorf("could not decode patch: %w", err)
+		}
+		if content, err = streams.ApplyPatch(content, ops); err != nil {
+			return err
+		}
+	}
+	return v.Validate(schemaID, content)
+}
+
+func (v *Validator) getSchema(schemaID string) (*jsonschema.Schema, error) {
+	now := v.now()
+	v.mu.RLock()
+	cached, ok := v.schemas[schemaID]
+	v.mu.RUnlock()
+	if ok && (cached.expires.IsZero() || now.Before(cached.expires)) {
+		return cached.schema, nil
+	}
+
+	resp, err := v.ceramic.GetStreamState(api.StreamStateRequest{StreamID: schemaID})
+	if err != nil {
+		return nil, fmt.Errorf("could not load schema<%s>: %w", schemaID, err)
+	}
+	if resp.ResponseCode != http.StatusOK {
+		return nil, fmt.Errorf("could not load schema<%s>: status %d", schemaID, resp.ResponseCode)
+	}
+	if resp.Response.Content == nil {
+		return nil, fmt.Errorf("schema<%s> has no content", schemaID)
+	}
+
+	url := schemaURLPrefix + schemaID
+	compiler := jsonschema.NewCompiler()
+	// remote references are not resolved, schemas must be self contained
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("cannot load schema reference<%s>", s)
+	}
+	if err := compiler.AddResource(url, bytes.NewReader(*resp.Response.Content)); err != nil {
+		return nil, fmt.Errorf("invalid schema<%s>: %w", schemaID, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema<%s>: %w", schemaID, err)
+	}
+
+	cached = cachedSchema{schema: compiled}
+	if !isPinned(schemaID) {
+		cached.expires = now.Add(SchemaTTL)
+	}
+	v.mu.Lock()
+	v.schemas[schemaID] = cached
+	v.mu.Unlock()
+	return compiled, nil
+}
+
+// isPinned reports whether id is a commit ID, whose content cannot change, rather than a stream ID.
+func isPinned(id string) bool {
+	commitID, err := streams.ParseCommitID(id)
+	// a stream ID parses as its genesis commit but is shorter than that commit ID
+	return err == nil && commitID.String() == id
+}
+
+// decodeCommit accepts a commit in any form the API requests carry, typed or generic, signed or unsigned.
+func decodeCommit(commit interface{}) (*streams.CommitData, error) {
+	commitBytes, err := json.Marshal(commit)
+	if err != nil {
+		return nil, err
+	}
+	value := json.RawMessage(commitBytes)
+	return streams.DecodeCommit(streams.CommitRecord{Value: &value})
+}
+
+func toJSONValue(content interface{}) (interface{}, error) {
+	var contentBytes []byte
+	switch c := content.(type) {
+	case *json.RawMessage:
+		contentBytes = *c
+	case json.RawMessage:
+		contentBytes = c
+	default:
+		b, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		contentBytes = b
+	}
+	decoder := json.NewDecoder(bytes.NewReader(contentBytes))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("could not decode content: %w", err)
+	}
+	return v, nil
+}
+
+// fieldErrors flattens the tree of schema errors into the leaf errors, which name the offending fields.
+func fieldErrors(err *jsonschema.ValidationError) []FieldError {
+	if len(err.Causes) == 0 {
+		return []FieldError{{Path: err.InstanceLocation, Message: err.Message}}
+	}
+	var fields []FieldError
+	for _, cause := range err.Causes {
+		fields = append(fields, fieldErrors(cause)...)
+	}
+	return fields
+}
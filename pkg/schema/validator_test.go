@@ -0,0 +1,231 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	testSchemaID = "k3y52l7qbv1frxt706gqfzmq6cbqdkptzk8uudaryhlkf6ly9vx21hqu4r6k1jqio"
+	testStreamID = "k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl"
+	testSchema   = `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"title": {"type": "string", "maxLength": 10},
+			"count": {"type": "integer", "minimum": 0}
+		},
+		"required": ["title"]
+	}`
+)
+
+type fakeCeramic struct {
+	api.CeramicAPI
+	states  map[string]streams.StreamState
+	loads   map[string]int
+	codes   map[string]int
+	created int
+	applied int
+}
+
+func newFakeCeramic() *fakeCeramic {
+	schema := json.RawMessage(testSchema)
+	content := json.RawMessage(`{"title":"hello","count":1}`)
+	return &fakeCeramic{
+		states: map[string]streams.StreamState{
+			testSchemaID: {Content: &schema},
+			testStreamID: {Content: &content, Metadata: streams.StreamMetadata{Schema: testSchemaID}},
+		},
+		loads: make(map[string]int),
+		codes: make(map[string]int),
+	}
+}
+
+func (f *fakeCeramic) GetStreamState(req api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	f.loads[req.StreamID]++
+	if code, ok := f.codes[req.StreamID]; ok {
+		return &api.StreamStateResponse{ResponseCode: code}, nil
+	}
+	state, ok := f.states[req.StreamID]
+	if !ok {
+		return nil, fmt.Errorf("stream<%s> not found", req.StreamID)
+	}
+	return &api.StreamStateResponse{Response: state, ResponseCode: http.StatusOK}, nil
+}
+
+func (f *fakeCeramic) CreateStream(api.CreateStreamRequest) (*api.CreateStreamResponse, error) {
+	f.created++
+	return &api.CreateStreamResponse{ResponseCode: http.StatusOK}, nil
+}
+
+func (f *fakeCeramic) ApplyCommit(api.ApplyCommitRequest) (*api.ApplyCommitResponse, error) {
+	f.applied++
+	return &api.ApplyCommitResponse{ResponseCode: http.StatusOK}, nil
+}
+
+func TestValidator(t *testing.T) {
+	ceramic := newFakeCeramic()
+	validator := NewValidator(ceramic)
+
+	t.Run("valid content", func(tt *testing.T) {
+		assert.NoError(tt, validator.Validate(testSchemaID, map[string]interface{}{"title": "ok", "count": 3}))
+	})
+
+	t.Run("no schema", func(tt *testing.T) {
+		assert.NoError(tt, validator.Validate("", map[string]interface{}{"title": 1}))
+	})
+
+	t.Run("field errors", func(tt *testing.T) {
+		err := validator.Validate(testSchemaID, json.RawMessage(`{"title":"far too long a title","count":-1}`))
+		assert.Error(tt, err)
+		validationErr, ok := err.(*ValidationError)
+		assert.True(tt, ok)
+		assert.Equal(tt, testSchemaID, validationErr.Schema)
+
+		var paths []string
+		for _, field := range validationErr.Fields {
+			paths = append(paths, field.Path)
+		}
+		assert.ElementsMatch(tt, []string{"/title", "/count"}, paths)
+		assert.Contains(tt, err.Error(), "/title")
+	})
+
+	t.Run("missing required field", func(tt *testing.T) {
+		err := validator.Validate(testSchemaID, map[string]interface{}{"count": 1})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "title")
+	})
+
+	t.Run("compiled schemas are cached", func(tt *testing.T) {
+		assert.Equal(tt, 1, ceramic.loads[testSchemaID])
+	})
+
+	t.Run("unknown schema", func(tt *testing.T) {
+		err := validator.Validate("k3y52unknown", map[string]interface{}{})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "could not load schema")
+	})
+
+	t.Run("error status", func(tt *testing.T) {
+		ceramic.codes["k3y52missing"] = http.StatusNotFound
+		err := validator.Validate("k3y52missing", map[string]interface{}{})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 404")
+	})
+}
+
+func TestSchemaCache(t *testing.T) {
+	ceramic := newFakeCeramic()
+	validator := NewValidator(ceramic)
+	now := time.Now()
+	validator.now = func() time.Time { return now }
+
+	commitID, err := streams.ParseCommitID(testSchemaID)
+	assert.NoError(t, err)
+	streamID := commitID.StreamID.String()
+	ceramic.states[streamID] = ceramic.states[testSchemaID]
+
+	for _, schemaID := range []string{streamID, testSchemaID} {
+		assert.NoError(t, validator.Validate(schemaID, map[string]interface{}{"title": "ok"}))
+		assert.NoError(t, validator.Validate(schemaID, map[string]interface{}{"title": "ok"}))
+		assert.Equal(t, 1, ceramic.loads[schemaID])
+	}
+
+	t.Run("stream ids expire", func(tt *testing.T) {
+		now = now.Add(SchemaTTL)
+		assert.NoError(tt, validator.Validate(streamID, map[string]interface{}{"title": "ok"}))
+		assert.Equal(tt, 2, ceramic.loads[streamID])
+	})
+
+	t.Run("commit ids are kept", func(tt *testing.T) {
+		assert.NoError(tt, validator.Validate(testSchemaID, map[string]interface{}{"title": "ok"}))
+		assert.Equal(tt, 1, ceramic.loads[testSchemaID])
+	})
+}
+
+func TestValidatingClient(t *testing.T) {
+	ceramic := newFakeCeramic()
+	client := NewValidatingClient(ceramic)
+
+	genesis := func(title string) map[string]interface{} {
+		return map[string]interface{}{
+			"header": map[string]interface{}{"controllers": []string{"did:key:z6Mk"}, "schema": testSchemaID},
+			"data":   map[string]interface{}{"title": title},
+		}
+	}
+
+	t.Run("create stream", func(tt *testing.T) {
+		_, err := client.CreateStream(api.CreateStreamRequest{Genesis: genesis("ok")})
+		assert.NoError(tt, err)
+		assert.Equal(tt, 1, ceramic.created)
+
+		_, err = client.CreateStream(api.CreateStreamRequest{Genesis: genesis("far too long a title")})
+		assert.Error(tt, err)
+		assert.IsType(tt, &ValidationError{}, err)
+		assert.Equal(tt, 1, ceramic.created)
+	})
+
+	commit := func(patch string) streams.RawCommit {
+		data := json.RawMessage(patch)
+		return streams.RawCommit{ID: "bafygenesis", Prev: "bafytip", Data: &data}
+	}
+
+	t.Run("apply commit", func(tt *testing.T) {
+		_, err := client.ApplyCommit(api.ApplyCommitRequest{
+			StreamID: testStreamID,
+			Commit:   commit(`[{"op":"replace","path":"/title","value":"updated"}]`),
+		})
+		assert.NoError(tt, err)
+		assert.Equal(tt, 1, ceramic.applied)
+
+		_, err = client.ApplyCommit(api.ApplyCommitRequest{
+			StreamID: testStreamID,
+			Commit:   commit(`[{"op":"remove","path":"/title"},{"op":"replace","path":"/count","value":1.5}]`),
+		})
+		assert.Error(tt, err)
+		validationErr, ok := err.(*ValidationError)
+		assert.True(tt, ok)
+		assert.Len(tt, validationErr.Fields, 2)
+		assert.Equal(tt, 1, ceramic.applied)
+	})
+
+	t.Run("pending metadata change", func(tt *testing.T) {
+		// a pending change that adds a schema and leaves the content as it is
+		content := json.RawMessage(`{"title":"hello"}`)
+		ceramic.states["k2t6pending"] = streams.StreamState{
+			Content: &content,
+			Next: streams.StreamNext{
+				Metadata: streams.StreamMetadata{Controllers: []string{"did:key:z6Mk"}, Schema: testSchemaID},
+			},
+		}
+		_, err := client.ApplyCommit(api.ApplyCommitRequest{
+			StreamID: "k2t6pending",
+			Commit:   commit(`[{"op":"replace","path":"/title","value":"far too long a title"}]`),
+		})
+		assert.IsType(tt, &ValidationError{}, err)
+	})
+
+	t.Run("stream fails to load", func(tt *testing.T) {
+		ceramic.codes["k2t6missing"] = http.StatusNotFound
+		_, err := client.ApplyCommit(api.ApplyCommitRequest{
+			StreamID: "k2t6missing",
+			Commit:   commit(`[{"op":"replace","path":"/title","value":"far too long a title"}]`),
+		})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 404")
+	})
+
+	t.Run("anchor commits are not validated", func(tt *testing.T) {
+		_, err := client.ApplyCommit(api.ApplyCommitRequest{
+			StreamID: testStreamID,
+			Commit:   streams.AnchorCommit{ID: "bafygenesis", Prev: "bafytip", Proof: "bafyproof"},
+		})
+		assert.NoError(tt, err)
+	})
+}
@@ -0,0 +1,125 @@
+package streams
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ConflictReason string
+
+const (
+	// IdenticalLogs the two histories are the same
+	IdenticalLogs ConflictReason = "identical"
+	// ExtendedLog one history is a prefix of the other, so the longer one wins without a fork
+	ExtendedLog ConflictReason = "extended"
+	// EarlierAnchor the winner was anchored first after the point of divergence
+	EarlierAnchor ConflictReason = "earlierAnchor"
+	// LongerLog neither history was anchored earlier, the winner has more commits
+	LongerLog ConflictReason = "longerLog"
+	// TieBreak the histories could not be told apart by the rules above, the winner has the lower divergent CID
+	TieBreak ConflictReason = "tieBreak"
+)
+
+// LogHistory is a stream log as reported by one node, along with the proofs of its anchor commits keyed by the anchor
+// commit's CID. A missing proof falls back to the log entry's timestamp. An anchor whose time is unknown, with a zero
+// timestamp, counts as anchored after every anchor whose time is known.
+type LogHistory struct {
+	Log    []LogEntry
+	Proofs map[string]AnchorProof
+}
+
+type ConflictResolution struct {
+	Winner LogHistory
+	Loser  LogHistory
+	// WinnerIsFirst whether the winner is the first history passed in
+	WinnerIsFirst bool
+	// DivergedAt the index of the first log entry that differs between the histories; entries before it are shared
+	DivergedAt int
+	// Forked true when both histories contain commits the other does not
+	Forked bool
+	Reason ConflictReason
+}
+
+// ResolveConflict picks the canonical history of a stream between two logs using Ceramic's rules: the log whose
+// divergent part was anchored first wins, then the longer log, then the log with the lexically lower CID at the point
+// of divergence.
+func ResolveConflict(first, second LogHistory) (*ConflictResolution, error) {
+	if len(first.Log) == 0 || len(second.Log) == 0 {
+		return nil, errors.New("cannot resolve conflict for an empty log")
+	}
+	if first.Log[0].CID != second.Log[0].CID {
+		return nil, fmt.Errorf("logs belong to different streams: genesis<%s> and genesis<%s>", first.Log[0].CID, second.Log[0].CID)
+	}
+
+	diverged := 0
+	for diverged < len(first.Log) && diverged < len(second.Log) && first.Log[diverged].CID == second.Log[diverged].CID {
+		diverged++
+	}
+	resolution := ConflictResolution{DivergedAt: diverged}
+
+	firstWins, reason := pickLog(first, second, diverged)
+	resolution.WinnerIsFirst, resolution.Reason = firstWins, reason
+	resolution.Forked = diverged < len(first.Log) && diverged < len(second.Log)
+	if firstWins {
+		resolution.Winner, resolution.Loser = first, second
+	} else {
+		resolution.Winner, resolution.Loser = second, first
+	}
+	return &resolution, nil
+}
+
+func pickLog(first, second LogHistory, diverged int) (bool, ConflictReason) {
+	switch {
+	case diverged == len(first.Log) && diverged == len(second.Log):
+		return true, IdenticalLogs
+	case diverged == len(second.Log):
+		return true, ExtendedLog
+	case diverged == len(first.Log):
+		return false, ExtendedLog
+	}
+
+	firstAnchor, firstAnchored := first.earliestAnchor(diverged)
+	secondAnchor, secondAnchored := second.earliestAnchor(diverged)
+	switch {
+	case firstAnchored && !secondAnchored:
+		return true, EarlierAnchor
+	case secondAnchored && !firstAnchored:
+		return false, EarlierAnchor
+	case firstAnchored && secondAnchored && anchoredBefore(firstAnchor, secondAnchor):
+		return true, EarlierAnchor
+	case firstAnchored && secondAnchored && anchoredBefore(secondAnchor, firstAnchor):
+		return false, EarlierAnchor
+	}
+
+	if len(first.Log) != len(second.Log) {
+		return len(first.Log) > len(second.Log), LongerLog
+	}
+	return first.Log[diverged].CID < second.Log[diverged].CID, TieBreak
+}
+
+// earliestAnchor finds the proof of the first anchor commit at or after index from.
+func (h LogHistory) earliestAnchor(from int) (AnchorProof, bool) {
+	for _, entry := range h.Log[from:] {
+		if entry.Type != AnchorCommitType {
+			continue
+		}
+		if proof, ok := h.Proofs[entry.CID]; ok {
+			return proof, true
+		}
+		return AnchorProof{BlockTimestamp: entry.Timestamp}, true
+	}
+	return AnchorProof{}, false
+}
+
+func anchoredBefore(a, b AnchorProof) bool {
+	switch {
+	case a.BlockTimestamp == 0 && b.BlockTimestamp != 0:
+		return false
+	case b.BlockTimestamp == 0 && a.BlockTimestamp != 0:
+		return true
+	case a.BlockTimestamp != b.BlockTimestamp:
+		return a.BlockTimestamp < b.BlockTimestamp
+	}
+	// block numbers are only comparable on the same chain
+	return a.ChainID == b.ChainID && a.BlockNumber < b.BlockNumber
+}
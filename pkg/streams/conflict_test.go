@@ -0,0 +1,123 @@
+package streams
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveConflict(t *testing.T) {
+	genesis := LogEntry{CID: "bafygenesis", Type: GenesisCommitType}
+	shared := LogEntry{CID: "bafyshared", Type: SignedCommitType}
+
+	t.Run("different streams", func(tt *testing.T) {
+		_, err := ResolveConflict(LogHistory{Log: []LogEntry{genesis}}, LogHistory{Log: []LogEntry{{CID: "bafyother"}}})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "different streams")
+
+		_, err = ResolveConflict(LogHistory{}, LogHistory{Log: []LogEntry{genesis}})
+		assert.Error(tt, err)
+	})
+
+	t.Run("identical logs", func(tt *testing.T) {
+		log := LogHistory{Log: []LogEntry{genesis, shared}}
+		resolution, err := ResolveConflict(log, log)
+		assert.NoError(tt, err)
+		assert.Equal(tt, IdenticalLogs, resolution.Reason)
+		assert.Equal(tt, 2, resolution.DivergedAt)
+		assert.False(tt, resolution.Forked)
+	})
+
+	t.Run("extended log", func(tt *testing.T) {
+		short := LogHistory{Log: []LogEntry{genesis}}
+		long := LogHistory{Log: []LogEntry{genesis, shared}}
+		resolution, err := ResolveConflict(short, long)
+		assert.NoError(tt, err)
+		assert.Equal(tt, ExtendedLog, resolution.Reason)
+		assert.False(tt, resolution.WinnerIsFirst)
+		assert.False(tt, resolution.Forked)
+		assert.Equal(tt, 1, resolution.DivergedAt)
+		assert.Equal(tt, long, resolution.Winner)
+	})
+
+	t.Run("earlier anchor wins over longer log", func(tt *testing.T) {
+		anchored := LogHistory{
+			Log: []LogEntry{genesis, shared, {CID: "bafya1", Type: SignedCommitType}, {CID: "bafyanchor1", Type: AnchorCommitType}},
+			Proofs: map[string]AnchorProof{
+				"bafyanchor1": {ChainID: "eip155:3", BlockNumber: 10, BlockTimestamp: 1000},
+			},
+		}
+		later := LogHistory{
+			Log: []LogEntry{genesis, shared, {CID: "bafyb1", Type: SignedCommitType}, {CID: "bafyb2", Type: SignedCommitType},
+				{CID: "bafyanchor2", Type: AnchorCommitType, Timestamp: 2000}},
+		}
+		resolution, err := ResolveConflict(later, anchored)
+		assert.NoError(tt, err)
+		assert.Equal(tt, EarlierAnchor, resolution.Reason)
+		assert.False(tt, resolution.WinnerIsFirst)
+		assert.True(tt, resolution.Forked)
+		assert.Equal(tt, 2, resolution.DivergedAt)
+		assert.Equal(tt, anchored, resolution.Winner)
+		assert.Equal(tt, later, resolution.Loser)
+
+		unanchored := LogHistory{Log: []LogEntry{genesis, shared, {CID: "bafyc1", Type: SignedCommitType}}}
+		resolution, err = ResolveConflict(unanchored, later)
+		assert.NoError(tt, err)
+		assert.Equal(tt, EarlierAnchor, resolution.Reason)
+		assert.False(tt, resolution.WinnerIsFirst)
+	})
+
+	t.Run("anchor of unknown time sorts last", func(tt *testing.T) {
+		unknown := LogHistory{
+			Log: []LogEntry{genesis, {CID: "bafya1", Type: SignedCommitType}, {CID: "bafyanchora", Type: AnchorCommitType}},
+		}
+		known := LogHistory{
+			Log:    []LogEntry{genesis, {CID: "bafyb1", Type: SignedCommitType}, {CID: "bafyanchorb", Type: AnchorCommitType}},
+			Proofs: map[string]AnchorProof{"bafyanchorb": {ChainID: "eip155:3", BlockNumber: 10, BlockTimestamp: 1000}},
+		}
+		resolution, err := ResolveConflict(unknown, known)
+		assert.NoError(tt, err)
+		assert.Equal(tt, EarlierAnchor, resolution.Reason)
+		assert.Equal(tt, known, resolution.Winner)
+
+		resolution, err = ResolveConflict(known, unknown)
+		assert.NoError(tt, err)
+		assert.Equal(tt, known, resolution.Winner)
+
+		unanchored := LogHistory{Log: []LogEntry{genesis, {CID: "bafyc1", Type: SignedCommitType}, {CID: "bafyc2", Type: SignedCommitType}}}
+		resolution, err = ResolveConflict(unanchored, unknown)
+		assert.NoError(tt, err)
+		assert.Equal(tt, EarlierAnchor, resolution.Reason)
+		assert.Equal(tt, unknown, resolution.Winner)
+	})
+
+	t.Run("same anchor block falls back to longer log", func(tt *testing.T) {
+		proof := AnchorProof{ChainID: "eip155:3", BlockNumber: 10, BlockTimestamp: 1000}
+		a := LogHistory{
+			Log:    []LogEntry{genesis, {CID: "bafya1", Type: SignedCommitType}, {CID: "bafyanchora", Type: AnchorCommitType}},
+			Proofs: map[string]AnchorProof{"bafyanchora": proof},
+		}
+		b := LogHistory{
+			Log: []LogEntry{genesis, {CID: "bafyb1", Type: SignedCommitType}, {CID: "bafyanchorb", Type: AnchorCommitType},
+				{CID: "bafyb2", Type: SignedCommitType}},
+			Proofs: map[string]AnchorProof{"bafyanchorb": proof},
+		}
+		resolution, err := ResolveConflict(a, b)
+		assert.NoError(tt, err)
+		assert.Equal(tt, LongerLog, resolution.Reason)
+		assert.False(tt, resolution.WinnerIsFirst)
+	})
+
+	t.Run("tie break is deterministic", func(tt *testing.T) {
+		a := LogHistory{Log: []LogEntry{genesis, {CID: "bafyb", Type: SignedCommitType}}}
+		b := LogHistory{Log: []LogEntry{genesis, {CID: "bafya", Type: SignedCommitType}}}
+
+		resolution, err := ResolveConflict(a, b)
+		assert.NoError(tt, err)
+		assert.Equal(tt, TieBreak, resolution.Reason)
+		assert.Equal(tt, b, resolution.Winner)
+
+		resolution, err = ResolveConflict(b, a)
+		assert.NoError(tt, err)
+		assert.Equal(tt, b, resolution.Winner)
+	})
+}
@@ -0,0 +1,502 @@
+package streams
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// https://datatracker.ietf.org/doc/html/rfc6902
+
+type PatchOp string
+
+const (
+	AddOp     PatchOp = "add"
+	RemoveOp  PatchOp = "remove"
+	ReplaceOp PatchOp = "replace"
+	MoveOp    PatchOp = "move"
+	CopyOp    PatchOp = "copy"
+	TestOp    PatchOp = "test"
+)
+
+type PatchOperation struct {
+	Op    PatchOp          `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+type ArrayDiffMode int
+
+const (
+	// DiffArraysByIndex compares arrays element by element, recursing into changed elements and adding or removing
+	// trailing elements. Falls back to a single replace when that is smaller.
+	DiffArraysByIndex ArrayDiffMode = iota
+	// ReplaceArrays replaces any array that changed as a whole.
+	ReplaceArrays
+)
+
+// numberPrecision is the precision in bits numbers are compared at, well beyond that of any float64 or int64.
+const numberPrecision = 256
+
+type PatchOpts struct {
+	Arrays ArrayDiffMode
+}
+
+// CreatePatch produces a JSON Patch that transforms current into desired. current is typically StreamState.Content;
+// desired may be any value that marshals to JSON. An empty patch means the two are equal.
+func CreatePatch(current *json.RawMessage, desired interface{}, opts PatchOpts) ([]PatchOperation, error) {
+	from, err := decodeContent(current)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalize(desired)
+	if err != nil {
+		return nil, err
+	}
+	var ops []PatchOperation
+	if err := diff(&ops, "", from, to, opts); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// NewPatchCommit builds an update commit for the stream whose data is the patch from the state's content, including
+// any pending changes, to desired. The commit links to the genesis commit and to the current tip of the state's log.
+func NewPatchCommit(state StreamState, desired interface{}, opts PatchOpts) (*RawCommit, error) {
+	if len(state.Log) == 0 {
+		return nil, errors.New("stream state has no log entries")
+	}
+	current := state.Content
+	if HasPendingChanges(state) {
+		current = state.Next.Content
+	}
+	ops, err := CreatePatch(current, desired, opts)
+	if err != nil {
+		return nil, err
+	}
+	if ops == nil {
+		ops = []PatchOperation{}
+	}
+	patchBytes, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	data := json.RawMessage(patchBytes)
+	return &RawCommit{
+		ID:   state.Log[0].CID,
+		Data: &data,
+		Prev: state.Log[len(state.Log)-1].CID,
+	}, nil
+}
+
+func diff(ops *[]PatchOperation, path string, from, to interface{}, opts PatchOpts) error {
+	if jsonEqual(from, to) {
+		return nil
+	}
+	switch f := from.(type) {
+	case map[string]interface{}:
+		if t, ok := to.(map[string]interface{}); ok {
+			return diffObjects(ops, path, f, t, opts)
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok && opts.Arrays == DiffArraysByIndex {
+			return diffArrays(ops, path, f, t, opts)
+		}
+	}
+	return appendOp(ops, ReplaceOp, path, to)
+}
+
+func diffObjects(ops *[]PatchOperation, path string, from, to map[string]interface{}, opts PatchOpts) error {
+	for _, key := range sortedKeys(from) {
+		if _, ok := to[key]; !ok {
+			*ops = append(*ops, PatchOperation{Op: RemoveOp, Path: path + "/" + escapePointer(key)})
+		}
+	}
+	for _, key := range sortedKeys(to) {
+		keyPath := path + "/" + escapePointer(key)
+		fromValue, ok := from[key]
+		if !ok {
+			if err := appendOp(ops, AddOp, keyPath, to[key]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := diff(ops, keyPath, fromValue, to[key], opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffArrays(ops *[]PatchOperation, path string, from, to []interface{}, opts PatchOpts) error {
+	var arrayOps []PatchOperation
+	common := len(from)
+	if len(to) < common {
+		common = len(to)
+	}
+	for i := 0; i < common; i++ {
+		if err := diff(&arrayOps, path+"/"+strconv.Itoa(i), from[i], to[i], opts); err != nil {
+			return err
+		}
+	}
+	for i := common; i < len(to); i++ {
+		if err := appendOp(&arrayOps, AddOp, path+"/-", to[i]); err != nil {
+			return err
+		}
+	}
+	// remove from the back so earlier indices stay valid
+	for i := len(from) - 1; i >= common; i-- {
+		arrayOps = append(arrayOps, PatchOperation{Op: RemoveOp, Path: path + "/" + strconv.Itoa(i)})
+	}
+
+	var replaceOps []PatchOperation
+	if err := appendOp(&replaceOps, ReplaceOp, path, to); err != nil {
+		return err
+	}
+	if len(arrayOps) > 1 && patchSize(replaceOps) <= patchSize(arrayOps) {
+		arrayOps = replaceOps
+	}
+	*ops = append(*ops, arrayOps...)
+	return nil
+}
+
+func appendOp(ops *[]PatchOperation, op PatchOp, path string, value interface{}) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	raw := json.RawMessage(valueBytes)
+	*ops = append(*ops, PatchOperation{Op: op, Path: path, Value: &raw})
+	return nil
+}
+
+func patchSize(ops []PatchOperation) int {
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+func decodeContent(content *json.RawMessage) (interface{}, error) {
+	if content == nil || len(*content) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	return decodeJSON(*content)
+}
+
+// normalize converts v into the generic representation produced by decoding JSON, so that structs, maps and raw
+// messages all compare the same way.
+func normalize(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case json.RawMessage:
+		return decodeJSON(value)
+	case *json.RawMessage:
+		return decodeContent(value)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(b)
+}
+
+func decodeJSON(b []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("could not decode content: %w", err)
+	}
+	return v, nil
+}
+
+// jsonEqual compares two decoded JSON values, treating numbers as equal when their values are, so that 1, 1.0 and
+// 1e0 do not differ.
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if av == bv {
+			return true
+		}
+		af, _, aErr := big.ParseFloat(string(av), 10, numberPrecision, big.ToNearestEven)
+		bf, _, bErr := big.ParseFloat(string(bv), 10, numberPrecision, big.ToNearestEven)
+		return aErr == nil && bErr == nil && af.Cmp(bf) == 0
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a single JSON Pointer reference token https://datatracker.ietf.org/doc/html/rfc6901#section-3
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// ApplyPatch applies a JSON Patch to content and returns the patched document.
+func ApplyPatch(content *json.RawMessage, ops []PatchOperation) (*json.RawMessage, error) {
+	doc, err := decodeContent(content)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("could not apply patch operation %d <%s %s>: %w", i, op.Op, op.Path, err)
+		}
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	patched := json.RawMessage(b)
+	return &patched, nil
+}
+
+func applyOp(doc interface{}, op PatchOperation) (interface{}, error) {
+	switch op.Op {
+	case AddOp, ReplaceOp, TestOp:
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		value, err := decodeJSON(*op.Value)
+		if err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case AddOp:
+			return addValue(doc, op.Path, value)
+		case ReplaceOp:
+			if _, err := getValue(doc, op.Path); err != nil {
+				return nil, err
+			}
+			if op.Path == "" {
+				return value, nil
+			}
+			if doc, err = removeValue(doc, op.Path); err != nil {
+				return nil, err
+			}
+			return addValue(doc, op.Path, value)
+		default:
+			current, err := getValue(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !jsonEqual(current, value) {
+				return nil, errors.New("test failed")
+			}
+			return doc, nil
+		}
+	case RemoveOp:
+		return removeValue(doc, op.Path)
+	case MoveOp, CopyOp:
+		value, err := getValue(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == MoveOp {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+			if doc, err = removeValue(doc, op.From); err != nil {
+				return nil, err
+			}
+		} else if value, err = normalize(value); err != nil {
+			return nil, err
+		}
+		return addValue(doc, op.Path, value)
+	default:
+		return nil, fmt.Errorf("unsupported operation: %s", op.Op)
+	}
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer: %s", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index out of bounds: %d", i)
+	}
+	return i, nil
+}
+
+func getValue(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range tokens {
+		switch c := current.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %s", pointer)
+			}
+			current = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			current = c[i]
+		default:
+			return nil, fmt.Errorf("path not found: %s", pointer)
+		}
+	}
+	return current, nil
+}
+
+// updateParent locates the container addressed by all but the last token of pointer and replaces it with the result
+// of update, rebuilding the document on the way back up.
+func updateParent(doc interface{}, pointer string, update func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return update(nil, "")
+	}
+	return updateAt(doc, tokens, update)
+}
+
+func updateAt(current interface{}, tokens []string, update func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(current, tokens[0])
+	}
+	switch c := current.(type) {
+	case map[string]interface{}:
+		child, ok := c[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path not found: %s", tokens[0])
+		}
+		updated, err := updateAt(child, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		c[tokens[0]] = updated
+		return c, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(c), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateAt(c[i], tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = updated
+		return c, nil
+	default:
+		return nil, fmt.Errorf("path not found: %s", tokens[0])
+	}
+}
+
+func addValue(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	return updateParent(doc, pointer, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case nil:
+			return value, nil
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("cannot add to path: %s", pointer)
+		}
+	})
+}
+
+func removeValue(doc interface{}, pointer string) (interface{}, error) {
+	if pointer == "" {
+		return nil, errors.New("cannot remove the document root")
+	}
+	return updateParent(doc, pointer, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("path not found: %s", pointer)
+			}
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path not found: %s", pointer)
+		}
+	})
+}
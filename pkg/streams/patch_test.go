@@ -0,0 +1,146 @@
+package streams
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func rawJSON(s string) *json.RawMessage {
+	raw := json.RawMessage(s)
+	return &raw
+}
+
+func TestCreatePatch(t *testing.T) {
+	t.Run("equal content", func(tt *testing.T) {
+		ops, err := CreatePatch(rawJSON(`{"a":1,"b":[1,2]}`), map[string]interface{}{"b": []int{1, 2}, "a": 1}, PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Empty(tt, ops)
+	})
+
+	t.Run("numbers compare by value", func(tt *testing.T) {
+		ops, err := CreatePatch(rawJSON(`{"a":1,"b":100,"c":[0.5],"d":-0}`), rawJSON(`{"a":1.0,"b":1e2,"c":[5E-1],"d":0}`), PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Empty(tt, ops)
+
+		ops, err = CreatePatch(rawJSON(`{"a":9007199254740993}`), rawJSON(`{"a":9007199254740992}`), PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+	})
+
+	t.Run("object changes", func(tt *testing.T) {
+		ops, err := CreatePatch(rawJSON(`{"a":1,"b":{"c":"x","d":true},"e/f":null}`),
+			rawJSON(`{"a":2,"b":{"c":"x"},"g":null}`), PatchOpts{})
+		assert.NoError(tt, err)
+
+		patch, err := json.Marshal(ops)
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `[
+			{"op":"remove","path":"/e~1f"},
+			{"op":"replace","path":"/a","value":2},
+			{"op":"remove","path":"/b/d"},
+			{"op":"add","path":"/g","value":null}
+		]`, string(patch))
+	})
+
+	t.Run("nil content", func(tt *testing.T) {
+		ops, err := CreatePatch(nil, map[string]string{"title": "hello"}, PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+		assert.Equal(tt, AddOp, ops[0].Op)
+		assert.Equal(tt, "/title", ops[0].Path)
+	})
+
+	t.Run("arrays by index", func(tt *testing.T) {
+		ops, err := CreatePatch(rawJSON(`{"list":[{"n":1},{"n":2},{"n":3},{"n":4}]}`),
+			rawJSON(`{"list":[{"n":1},{"n":5},{"n":3},{"n":4}]}`), PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+		assert.Equal(tt, ReplaceOp, ops[0].Op)
+		assert.Equal(tt, "/list/1/n", ops[0].Path)
+
+		ops, err = CreatePatch(rawJSON(`{"list":[1,2,3,4,5,6]}`), rawJSON(`{"list":[1,2,3,4,5,6,7]}`), PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+		assert.Equal(tt, AddOp, ops[0].Op)
+		assert.Equal(tt, "/list/-", ops[0].Path)
+
+		ops, err = CreatePatch(rawJSON(`{"list":[1,2,3,4,5,6]}`), rawJSON(`{"list":[1,2,3,4]}`), PatchOpts{})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+		assert.Equal(tt, ReplaceOp, ops[0].Op)
+		assert.Equal(tt, "/list", ops[0].Path)
+	})
+
+	t.Run("replace arrays", func(tt *testing.T) {
+		ops, err := CreatePatch(rawJSON(`{"list":[{"n":1},{"n":2}]}`), rawJSON(`{"list":[{"n":1},{"n":3}]}`),
+			PatchOpts{Arrays: ReplaceArrays})
+		assert.NoError(tt, err)
+		assert.Len(tt, ops, 1)
+		assert.Equal(tt, ReplaceOp, ops[0].Op)
+		assert.Equal(tt, "/list", ops[0].Path)
+		assert.JSONEq(tt, `[{"n":1},{"n":3}]`, string(*ops[0].Value))
+	})
+
+	t.Run("bad content", func(tt *testing.T) {
+		_, err := CreatePatch(rawJSON(`{"a":`), map[string]string{}, PatchOpts{})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "could not decode content")
+	})
+}
+
+func TestNewPatchCommit(t *testing.T) {
+	state := StreamState{
+		Content: rawJSON(`{"title":"old"}`),
+		Log: []LogEntry{
+			{CID: "bafygenesis", Type: GenesisCommitType},
+			{CID: "bafytip", Type: SignedCommitType},
+		},
+	}
+
+	commit, err := NewPatchCommit(state, map[string]string{"title": "new"}, PatchOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, "bafygenesis", commit.ID)
+	assert.Equal(t, "bafytip", commit.Prev)
+	assert.JSONEq(t, `[{"op":"replace","path":"/title","value":"new"}]`, string(*commit.Data))
+
+	_, err = NewPatchCommit(StreamState{}, map[string]string{}, PatchOpts{})
+	assert.Error(t, err)
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Run("round trip", func(tt *testing.T) {
+		current := rawJSON(`{"a":1,"b":{"c":[1,2,3]},"d":"x"}`)
+		desired := rawJSON(`{"a":2,"b":{"c":[1,5]},"e":{"f":null}}`)
+		ops, err := CreatePatch(current, desired, PatchOpts{})
+		assert.NoError(tt, err)
+
+		patched, err := ApplyPatch(current, ops)
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, string(*desired), string(*patched))
+	})
+
+	t.Run("move, copy and test", func(tt *testing.T) {
+		patched, err := ApplyPatch(rawJSON(`{"a":{"b":1},"list":[1,2]}`), []PatchOperation{
+			{Op: TestOp, Path: "/a/b", Value: rawJSON(`1.0`)},
+			{Op: CopyOp, From: "/a", Path: "/c"},
+			{Op: MoveOp, From: "/a/b", Path: "/list/0"},
+		})
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `{"a":{},"c":{"b":1},"list":[1,1,2]}`, string(*patched))
+	})
+
+	t.Run("failures", func(tt *testing.T) {
+		_, err := ApplyPatch(rawJSON(`{"a":1}`), []PatchOperation{{Op: RemoveOp, Path: "/b"}})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "path not found")
+
+		_, err = ApplyPatch(rawJSON(`{"a":1}`), []PatchOperation{{Op: TestOp, Path: "/a", Value: rawJSON(`2`)}})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "test failed")
+
+		_, err = ApplyPatch(rawJSON(`{"list":[1]}`), []PatchOperation{{Op: AddOp, Path: "/list/3", Value: rawJSON(`1`)}})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "out of bounds")
+	})
+}
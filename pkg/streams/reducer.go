@@ -0,0 +1,354 @@
+package streams
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/internal"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"reflect"
+)
+
+const (
+	TileDocType       = "tile"
+	CAIP10LinkDocType = "caip10-link"
+)
+
+var ErrControllerChangeForbidden = errors.New("the stream forbids controller changes")
+
+// DecodeCommit converts a record returned by GetCommits into CommitData for the reducer. Anchor proofs are not part
+// of the record, so the caller must fill in CommitData.Proof for anchor commits to carry their proof and timestamp.
+func DecodeCommit(record CommitRecord) (*CommitData, error) {
+	if record.Value == nil {
+		return nil, fmt.Errorf("commit<%s> has no value", record.CID)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(*record.Value, &fields); err != nil {
+		return nil, fmt.Errorf("could not decode commit<%s>: %w", record.CID, err)
+	}
+
+	data := CommitData{LogEntry: LogEntry{CID: record.CID}}
+	if _, ok := fields["jws"]; ok {
+		var signed SignedCommit
+		if err := json.Unmarshal(*record.Value, &signed); err != nil {
+			return nil, fmt.Errorf("could not decode signed commit<%s>: %w", record.CID, err)
+		}
+		block, err := base64.StdEncoding.DecodeString(signed.LinkedBlock)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode linked block of commit<%s>: %w", record.CID, err)
+		}
+		payload, err := dagcbor.DecodeJSON(block)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode linked block of commit<%s>: %w", record.CID, err)
+		}
+		fields = nil
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, fmt.Errorf("could not decode payload of commit<%s>: %w", record.CID, err)
+		}
+		data.Commit = (*json.RawMessage)(&payload)
+		data.Envelope = signed.JWS
+		data.LinkedBlock = block
+		if signed.CacaoBlock != "" {
+			if data.CapabilityBlock, err = base64.StdEncoding.DecodeString(signed.CacaoBlock); err != nil {
+				return nil, fmt.Errorf("could not decode cacao block of commit<%s>: %w", record.CID, err)
+			}
+		}
+	} else {
+		data.Commit = record.Value
+	}
+
+	switch {
+	case fields["proof"] != nil:
+		data.Type = AnchorCommitType
+	case fields["prev"] != nil:
+		data.Type = SignedCommitType
+	default:
+		data.Type = GenesisCommitType
+	}
+	return &data, nil
+}
+
+// ReduceCommits replays the log of a stream of the given type, starting with its genesis commit, into the resulting
+// StreamState.
+func ReduceCommits(streamType StreamType, commits []CommitData) (*StreamState, error) {
+	if len(commits) == 0 {
+		return nil, errors.New("no commits to reduce")
+	}
+	state, err := ApplyGenesis(streamType, commits[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, commit := range commits[1:] {
+		if state, err = ApplyCommit(*state, commit); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// ApplyGenesis creates the initial state of a stream of the given type from its genesis commit. The genesis commit
+// does not name its stream type, which is part of the StreamID.
+func ApplyGenesis(streamType StreamType, genesis CommitData) (*StreamState, error) {
+	var docType string
+	switch streamType {
+	case Tile:
+		docType = TileDocType
+	case CAIP10Link:
+		docType = CAIP10LinkDocType
+	default:
+		return nil, fmt.Errorf("unsupported stream type<%d>", streamType)
+	}
+	if genesis.Commit == nil {
+		return nil, fmt.Errorf("genesis commit<%s> has no payload", genesis.CID)
+	}
+	var payload GenesisCommit
+	if err := json.Unmarshal(*genesis.Commit, &payload); err != nil {
+		return nil, fmt.Errorf("could not decode genesis commit<%s>: %w", genesis.CID, err)
+	}
+	if len(payload.Header.Controllers) == 0 {
+		return nil, fmt.Errorf("genesis commit<%s> has no controllers", genesis.CID)
+	}
+
+	content := payload.Data
+	if content == nil {
+		empty := json.RawMessage("{}")
+		content = &empty
+	}
+	signature := GenesisSigStatus
+	if len(genesis.Envelope.Signatures) > 0 {
+		signature = SignedSigStatus
+	}
+	header := payload.Header
+	return &StreamState{
+		Type:    uint64(streamType),
+		Content: content,
+		Metadata: StreamMetadata{
+			Controllers:            header.Controllers,
+			Family:                 header.Family,
+			Schema:                 header.Schema,
+			Tags:                   header.Tags,
+			ForbidControllerChange: header.ForbidControllerChange,
+			Index:                  header.Index,
+		},
+		Signature:    signature,
+		AnchorStatus: NotRequested,
+		Log:          []LogEntry{{CID: genesis.CID, Type: GenesisCommitType}},
+		DocType:      docType,
+	}, nil
+}
+
+// ApplyCommit applies a signed or anchor commit on top of state, returning the new state. state is left unchanged.
+func ApplyCommit(state StreamState, commit CommitData) (*StreamState, error) {
+	if len(state.Log) == 0 {
+		return nil, errors.New("cannot apply a commit to a stream without a genesis commit")
+	}
+	if commit.Commit == nil {
+		return nil, fmt.Errorf("commit<%s> has no payload", commit.CID)
+	}
+	var next StreamState
+	if err := internal.Copy(&state, &next); err != nil {
+		return nil, err
+	}
+	var err error
+	switch commit.Type {
+	case SignedCommitType:
+		err = applySigned(&next, commit)
+	case AnchorCommitType:
+		err = applyAnchor(&next, commit)
+	default:
+		err = fmt.Errorf("cannot apply commit<%s> of type %d to an existing stream", commit.CID, commit.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+func applySigned(state *StreamState, commit CommitData) error {
+	var payload RawCommit
+	if err := json.Unmarshal(*commit.Commit, &payload); err != nil {
+		return fmt.Errorf("could not decode signed commit<%s>: %w", commit.CID, err)
+	}
+	if err := checkLinks(*state, commit.CID, payload.ID, payload.Prev); err != nil {
+		return err
+	}
+
+	// updates build on any pending, not yet anchored, changes
+	content, metadata := state.Content, state.Metadata
+	if HasPendingChanges(*state) {
+		content, metadata = state.Next.Content, state.Next.Metadata
+	}
+	if metadata.ForbidControllerChange && len(payload.Header.Controllers) > 0 &&
+		!reflect.DeepEqual(payload.Header.Controllers, metadata.Controllers) {
+		return fmt.Errorf("could not apply commit<%s>: %w", commit.CID, ErrControllerChangeForbidden)
+	}
+	if payload.Data != nil {
+		var ops []PatchOperation
+		if err := json.Unmarshal(*payload.Data, &ops); err != nil {
+			return fmt.Errorf("could not decode patch of commit<%s>: %w", commit.CID, err)
+		}
+		patched, err := ApplyPatch(content, ops)
+		if err != nil {
+			return fmt.Errorf("could not apply patch of commit<%s>: %w", commit.CID, err)
+		}
+		content = patched
+	}
+	metadata = applyHeader(metadata, payload.Header)
+
+	state.Next = StreamNext{
+		Content:     content,
+		Controllers: metadata.Controllers,
+		Metadata:    metadata,
+	}
+	state.Signature = SignedSigStatus
+	state.AnchorStatus = NotRequested
+	state.Log = append(state.Log, LogEntry{CID: commit.CID, Type: SignedCommitType})
+	return nil
+}
+
+func applyAnchor(state *StreamState, commit CommitData) error {
+	var payload AnchorCommit
+	if err := json.Unmarshal(*commit.Commit, &payload); err != nil {
+		return fmt.Errorf("could not decode anchor commit<%s>: %w", commit.CID, err)
+	}
+	if err := checkLinks(*state, commit.CID, payload.ID, payload.Prev); err != nil {
+		return err
+	}
+
+	if HasPendingChanges(*state) {
+		state.Content = state.Next.Content
+		state.Metadata = state.Next.Metadata
+	}
+	state.Next = StreamNext{}
+	state.AnchorStatus = Anchored
+	state.AnchorProof = commit.Proof
+	state.AnchorScheduledFor = 0
+	state.Log = append(state.Log, LogEntry{
+		CID:       commit.CID,
+		Type:      AnchorCommitType,
+		Timestamp: commit.Proof.BlockTimestamp,
+	})
+	return nil
+}
+
+func checkLinks(state StreamState, cid, id, prev string) error {
+	if genesis := state.Log[0].CID; id != genesis {
+		return fmt.Errorf("commit<%s> belongs to stream with genesis<%s>, expected<%s>", cid, id, genesis)
+	}
+	if tip := state.Log[len(state.Log)-1].CID; prev != tip {
+		return fmt.Errorf("commit<%s> has prev<%s> but the stream tip is commit<%s>", cid, prev, tip)
+	}
+	return nil
+}
+
+// HasPendingChanges reports whether the state carries signed changes that are not anchored yet.
+func HasPendingChanges(state StreamState) bool {
+	return state.Next.Content != nil || len(state.Next.Metadata.Controllers) > 0
+}
+
+// applyHeader overlays the fields set in an update commit's header onto existing metadata.
+func applyHeader(metadata StreamMetadata, header CommitHeader) StreamMetadata {
+	if len(header.Controllers) > 0 {
+		metadata.Controllers = header.Controllers
+	}
+	if header.Family != "" {
+		metadata.Family = header.Family
+	}
+	if header.Schema != "" {
+		metadata.Schema = header.Schema
+	}
+	if header.Tags != nil {
+		metadata.Tags = header.Tags
+	}
+	if header.Index != nil {
+		metadata.Index = header.Index
+	}
+	return metadata
+}
+
+// DiffStates compares a locally reduced state with one returned by a node and returns the names of the fields that
+// differ. Content is compared semantically, so key order and whitespace do not matter.
+func DiffStates(local, remote StreamState) []string {
+	var diffs []string
+	if local.Type != remote.Type {
+		diffs = append(diffs, "type")
+	}
+	if !equalContent(local.Content, remote.Content) {
+		diffs = append(diffs, "content")
+	}
+	diffs = append(diffs, diffMetadata("metadata", local.Metadata, remote.Metadata)...)
+	if !equalContent(local.Next.Content, remote.Next.Content) {
+		diffs = append(diffs, "next.content")
+	}
+	diffs = append(diffs, diffMetadata("next.metadata", local.Next.Metadata, remote.Next.Metadata)...)
+	if local.Signature != remote.Signature {
+		diffs = append(diffs, "signature")
+	}
+	if local.AnchorStatus != remote.AnchorStatus {
+		diffs = append(diffs, "anchorStatus")
+	}
+	if local.AnchorProof != remote.AnchorProof {
+		diffs = append(diffs, "anchorProof")
+	}
+	if len(local.Log) != len(remote.Log) {
+		diffs = append(diffs, "log")
+	} else {
+		for i := range local.Log {
+			if local.Log[i].CID != remote.Log[i].CID || local.Log[i].Type != remote.Log[i].Type {
+				diffs = append(diffs, fmt.Sprintf("log[%d]", i))
+			}
+		}
+	}
+	return diffs
+}
+
+func diffMetadata(prefix string, local, remote StreamMetadata) []string {
+	var diffs []string
+	if !equalStrings(local.Controllers, remote.Controllers) {
+		diffs = append(diffs, prefix+".controllers")
+	}
+	if local.Family != remote.Family {
+		diffs = append(diffs, prefix+".family")
+	}
+	if local.Schema != remote.Schema {
+		diffs = append(diffs, prefix+".schema")
+	}
+	if !equalStrings(local.Tags, remote.Tags) {
+		diffs = append(diffs, prefix+".tags")
+	}
+	if local.ForbidControllerChange != remote.ForbidControllerChange {
+		diffs = append(diffs, prefix+".forbidControllerChange")
+	}
+	if !equalContent(local.Index, remote.Index) {
+		diffs = append(diffs, prefix+".index")
+	}
+	return diffs
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalContent(a, b *json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	left, err := decodeJSON(*a)
+	if err != nil {
+		return false
+	}
+	right, err := decodeJSON(*b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
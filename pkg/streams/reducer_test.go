@@ -0,0 +1,162 @@
+package streams
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testGenesisCID  = "bafyreihtdxfb6cpcvomm2c2elm3re2onqaix6frq4nbg45eaqszh5mifre"
+	testSignedCID   = "bafyreic6vh3eiuuzwztyjxl4tjw2gkmb5ypco7zcdcnkkjzaicxdllt33e"
+	testAnchorCID   = "bafyreiastagccuwzhjtrvmpxhx62ykswj2377tixkfxhpobze4y3iehjba"
+	testController  = "did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H"
+	testController2 = "did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8"
+)
+
+func testCommits(t *testing.T) []CommitData {
+	genesis, err := DecodeCommit(CommitRecord{
+		CID:   testGenesisCID,
+		Value: rawJSON(`{"header":{"family":"test","controllers":["` + testController + `"],"tags":["a"]},"data":{"title":"first","list":[1,2]}}`),
+	})
+	assert.NoError(t, err)
+
+	block, err := dagcbor.Encode(map[string]interface{}{
+		"id":     map[string]string{"/": testGenesisCID},
+		"prev":   map[string]string{"/": testGenesisCID},
+		"header": map[string]interface{}{"controllers": []string{testController2}},
+		"data":   []map[string]interface{}{{"op": "replace", "path": "/title", "value": "second"}, {"op": "add", "path": "/list/-", "value": 3}},
+	})
+	assert.NoError(t, err)
+	signedValue, err := json.Marshal(map[string]interface{}{
+		"jws": DAGJWS{
+			Payload:    "AXESIA",
+			Signatures: []JWSSignature{{Protected: "eyJhbGciOiJFZERTQSJ9", Signature: "c2ln"}},
+			Link:       testSignedCID,
+		},
+		"linkedBlock": base64.StdEncoding.EncodeToString(block),
+	})
+	assert.NoError(t, err)
+	signed, err := DecodeCommit(CommitRecord{CID: testSignedCID, Value: (*json.RawMessage)(&signedValue)})
+	assert.NoError(t, err)
+
+	anchor, err := DecodeCommit(CommitRecord{
+		CID:   testAnchorCID,
+		Value: rawJSON(`{"id":"` + testGenesisCID + `","prev":"` + testSignedCID + `","proof":"bafyproof","path":"0/1"}`),
+	})
+	assert.NoError(t, err)
+	anchor.Proof = AnchorProof{ChainID: "eip155:3", BlockNumber: 9542177, BlockTimestamp: 1611680505, TxHash: "bagjqcgza", Root: "bafyroot"}
+
+	return []CommitData{*genesis, *signed, *anchor}
+}
+
+func TestDecodeCommit(t *testing.T) {
+	commits := testCommits(t)
+	assert.Equal(t, GenesisCommitType, commits[0].Type)
+	assert.Equal(t, SignedCommitType, commits[1].Type)
+	assert.Equal(t, testSignedCID, commits[1].Envelope.Link)
+	assert.Equal(t, AnchorCommitType, commits[2].Type)
+
+	var payload RawCommit
+	assert.NoError(t, json.Unmarshal(*commits[1].Commit, &payload))
+	assert.Equal(t, testGenesisCID, payload.Prev)
+
+	_, err := DecodeCommit(CommitRecord{CID: testGenesisCID})
+	assert.Error(t, err)
+}
+
+func TestReduceCommits(t *testing.T) {
+	commits := testCommits(t)
+
+	t.Run("genesis only", func(tt *testing.T) {
+		state, err := ReduceCommits(Tile, commits[:1])
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `{"title":"first","list":[1,2]}`, string(*state.Content))
+		assert.Equal(tt, []string{testController}, state.Metadata.Controllers)
+		assert.Equal(tt, "test", state.Metadata.Family)
+		assert.Equal(tt, GenesisSigStatus, state.Signature)
+		assert.Equal(tt, NotRequested, state.AnchorStatus)
+		assert.Equal(tt, TileDocType, state.DocType)
+		assert.Len(tt, state.Log, 1)
+	})
+
+	t.Run("signed commit is pending until anchored", func(tt *testing.T) {
+		state, err := ReduceCommits(Tile, commits[:2])
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `{"title":"first","list":[1,2]}`, string(*state.Content))
+		assert.JSONEq(tt, `{"title":"second","list":[1,2,3]}`, string(*state.Next.Content))
+		assert.Equal(tt, []string{testController2}, state.Next.Metadata.Controllers)
+		assert.Equal(tt, []string{"a"}, state.Next.Metadata.Tags)
+		assert.Equal(tt, SignedSigStatus, state.Signature)
+		assert.Len(tt, state.Log, 2)
+	})
+
+	t.Run("anchor commit", func(tt *testing.T) {
+		state, err := ReduceCommits(Tile, commits)
+		assert.NoError(tt, err)
+		assert.JSONEq(tt, `{"title":"second","list":[1,2,3]}`, string(*state.Content))
+		assert.Nil(tt, state.Next.Content)
+		assert.Equal(tt, []string{testController2}, state.Metadata.Controllers)
+		assert.Equal(tt, Anchored, state.AnchorStatus)
+		assert.Equal(tt, commits[2].Proof, state.AnchorProof)
+		assert.Len(tt, state.Log, 3)
+		assert.Equal(tt, LogEntry{CID: testAnchorCID, Type: AnchorCommitType, Timestamp: 1611680505}, state.Log[2])
+	})
+
+	t.Run("controller change forbidden by genesis", func(tt *testing.T) {
+		genesis, err := ReduceCommits(Tile, commits[:1])
+		assert.NoError(tt, err)
+		genesis.Metadata.ForbidControllerChange = true
+		_, err = ApplyCommit(*genesis, commits[1])
+		assert.True(tt, errors.Is(err, ErrControllerChangeForbidden))
+	})
+
+	t.Run("commit out of order", func(tt *testing.T) {
+		_, err := ReduceCommits(Tile, []CommitData{commits[0], commits[2]})
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "but the stream tip is")
+	})
+
+	t.Run("no commits", func(tt *testing.T) {
+		_, err := ReduceCommits(Tile, nil)
+		assert.Error(tt, err)
+	})
+
+	t.Run("stream type", func(tt *testing.T) {
+		state, err := ReduceCommits(CAIP10Link, commits[:1])
+		assert.NoError(tt, err)
+		assert.Equal(tt, uint64(CAIP10Link), state.Type)
+		assert.Equal(tt, CAIP10LinkDocType, state.DocType)
+
+		_, err = ReduceCommits(StreamType(42), commits[:1])
+		assert.Error(tt, err)
+	})
+}
+
+func TestDiffStates(t *testing.T) {
+	local, err := ReduceCommits(Tile, testCommits(t))
+	assert.NoError(t, err)
+
+	var remote StreamState
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"type": 0,
+		"content": {"list": [1, 2, 3], "title": "second"},
+		"metadata": {"family": "test", "controllers": ["`+testController2+`"], "tags": ["a"]},
+		"signature": 2,
+		"anchorStatus": "ANCHORED",
+		"anchorProof": {"chainId": "eip155:3", "blockNumber": 9542177, "blockTimestamp": 1611680505, "txHash": "bagjqcgza", "root": "bafyroot"},
+		"log": [
+			{"cid": "`+testGenesisCID+`", "type": 0},
+			{"cid": "`+testSignedCID+`", "type": 1},
+			{"cid": "`+testAnchorCID+`", "type": 2, "timestamp": 1611680505}
+		]
+	}`), &remote))
+	assert.Empty(t, DiffStates(*local, remote))
+
+	remote.Metadata.Tags = nil
+	remote.Log[1].CID = "bafyother"
+	assert.Equal(t, []string{"metadata.tags", "log[1]"}, DiffStates(*local, remote))
+}
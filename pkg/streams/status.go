@@ -0,0 +1,132 @@
+package streams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The node sends these enums as their numeric codes, but serialized states may carry their names instead. All of
+// them decode from either form and encode to the numeric code.
+
+type SignatureStatus int
+
+const (
+	GenesisSigStatus SignatureStatus = iota
+	PartialSigStatus
+	SignedSigStatus
+)
+
+var signatureStatusNames = []string{"GENESIS", "PARTIAL", "SIGNED"}
+
+func (s SignatureStatus) String() string {
+	return enumName(signatureStatusNames, int(s))
+}
+
+func (s *SignatureStatus) UnmarshalJSON(b []byte) error {
+	code, err := decodeEnum(signatureStatusNames, "signature status", b)
+	if err != nil {
+		return err
+	}
+	*s = SignatureStatus(code)
+	return nil
+}
+
+type AnchorStatus int
+
+const (
+	NotRequested AnchorStatus = iota
+	Pending
+	Processing
+	Anchored
+	Failed
+)
+
+var anchorStatusNames = []string{"NOT_REQUESTED", "PENDING", "PROCESSING", "ANCHORED", "FAILED"}
+
+func (s AnchorStatus) String() string {
+	return enumName(anchorStatusNames, int(s))
+}
+
+func (s *AnchorStatus) UnmarshalJSON(b []byte) error {
+	code, err := decodeEnum(anchorStatusNames, "anchor status", b)
+	if err != nil {
+		return err
+	}
+	*s = AnchorStatus(code)
+	return nil
+}
+
+// CanTransitionTo reports whether a stream may move from status s to next. A request moves a stream to PENDING and
+// the anchor service then moves it through PROCESSING to ANCHORED or FAILED. A failed anchor may be requested again,
+// and a new commit resets any status to NOT_REQUESTED. An anchored stream has nothing to anchor until such a commit,
+// so it never becomes PENDING directly.
+func (s AnchorStatus) CanTransitionTo(next AnchorStatus) bool {
+	if s == next || next == NotRequested {
+		return true
+	}
+	switch s {
+	case NotRequested, Failed:
+		return next == Pending
+	case Pending:
+		return next == Processing || next == Anchored || next == Failed
+	case Processing:
+		return next == Anchored || next == Failed
+	}
+	return false
+}
+
+type CommitType int
+
+const (
+	GenesisCommitType CommitType = iota
+	SignedCommitType
+	AnchorCommitType
+)
+
+var commitTypeNames = []string{"GENESIS", "SIGNED", "ANCHOR"}
+
+func (t CommitType) String() string {
+	return enumName(commitTypeNames, int(t))
+}
+
+func (t *CommitType) UnmarshalJSON(b []byte) error {
+	code, err := decodeEnum(commitTypeNames, "commit type", b)
+	if err != nil {
+		return err
+	}
+	*t = CommitType(code)
+	return nil
+}
+
+func enumName(names []string, code int) string {
+	if code < 0 || code >= len(names) {
+		return strconv.Itoa(code)
+	}
+	return names[code]
+}
+
+func decodeEnum(names []string, kind string, b []byte) (int, error) {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var name string
+		if err := json.Unmarshal(b, &name); err != nil {
+			return 0, err
+		}
+		for code, n := range names {
+			if strings.EqualFold(n, name) {
+				return code, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown %s<%s>", kind, name)
+	}
+	var code int
+	if err := json.Unmarshal(b, &code); err != nil {
+		return 0, fmt.Errorf("invalid %s<%s>: %w", kind, string(b), err)
+	}
+	if code < 0 || code >= len(names) {
+		return 0, fmt.Errorf("unknown %s<%d>", kind, code)
+	}
+	return code, nil
+}
@@ -0,0 +1,77 @@
+package streams
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStatusDecoding(t *testing.T) {
+	t.Run("numeric and named forms", func(tt *testing.T) {
+		var state StreamState
+		err := json.Unmarshal([]byte(`{"signature":2,"anchorStatus":"ANCHORED","log":[{"type":0},{"type":"anchor"}]}`), &state)
+		assert.NoError(tt, err)
+		assert.Equal(tt, SignedSigStatus, state.Signature)
+		assert.Equal(tt, Anchored, state.AnchorStatus)
+		assert.Equal(tt, []LogEntry{{Type: GenesisCommitType}, {Type: AnchorCommitType}}, state.Log)
+
+		err = json.Unmarshal([]byte(`{"signature":"PARTIAL","anchorStatus":1}`), &state)
+		assert.NoError(tt, err)
+		assert.Equal(tt, PartialSigStatus, state.Signature)
+		assert.Equal(tt, Pending, state.AnchorStatus)
+	})
+
+	t.Run("encodes numeric codes", func(tt *testing.T) {
+		b, err := json.Marshal(StreamState{AnchorStatus: Processing, Signature: SignedSigStatus, Log: []LogEntry{{Type: SignedCommitType}}})
+		assert.NoError(tt, err)
+		assert.Contains(tt, string(b), `"anchorStatus":2`)
+		assert.Contains(tt, string(b), `"signature":2`)
+		assert.Contains(tt, string(b), `"type":1`)
+	})
+
+	t.Run("unknown values", func(tt *testing.T) {
+		var status AnchorStatus
+		assert.Error(tt, json.Unmarshal([]byte(`"DONE"`), &status))
+		assert.Error(tt, json.Unmarshal([]byte(`5`), &status))
+		assert.Error(tt, json.Unmarshal([]byte(`true`), &status))
+
+		var commitType CommitType
+		assert.Error(tt, json.Unmarshal([]byte(`-1`), &commitType))
+	})
+
+	t.Run("names", func(tt *testing.T) {
+		assert.Equal(tt, "NOT_REQUESTED", NotRequested.String())
+		assert.Equal(tt, "FAILED", Failed.String())
+		assert.Equal(tt, "GENESIS", GenesisSigStatus.String())
+		assert.Equal(tt, "ANCHOR", AnchorCommitType.String())
+		assert.Equal(tt, "9", AnchorStatus(9).String())
+	})
+}
+
+func TestAnchorStatusTransitions(t *testing.T) {
+	valid := []struct{ from, to AnchorStatus }{
+		{NotRequested, Pending},
+		{Pending, Processing},
+		{Pending, Anchored},
+		{Processing, Anchored},
+		{Processing, Failed},
+		{Failed, Pending},
+		{Anchored, NotRequested},
+		{Pending, Pending},
+	}
+	for _, transition := range valid {
+		assert.True(t, transition.from.CanTransitionTo(transition.to), "%s -> %s", transition.from, transition.to)
+	}
+
+	invalid := []struct{ from, to AnchorStatus }{
+		{NotRequested, Anchored},
+		{NotRequested, Processing},
+		{Processing, Pending},
+		{Failed, Anchored},
+		{Anchored, Failed},
+		{Anchored, Pending},
+	}
+	for _, transition := range invalid {
+		assert.False(t, transition.from.CanTransitionTo(transition.to), "%s -> %s", transition.from, transition.to)
+	}
+}
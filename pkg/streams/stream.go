@@ -2,7 +2,8 @@ package streams
 
 import (
 	"encoding/json"
-	"github.com/textileio/go-did-resolver/threeid"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
 )
 
 type StreamType int
@@ -14,31 +15,8 @@ const (
 	CAIP10Link
 )
 
-type SignatureStatus int
-
-const (
-	GenesisSigStatus SignatureStatus = iota
-	PartialSigStatus
-	SignedSigStatus
-)
-
-type AnchorStatus string
-
-const (
-	NotRequested AnchorStatus = "NOT_REQUESTED"
-	Pending                   = "PENDING"
-	Processing                = "PROCESSING"
-	Anchored                  = "ANCHORED"
-	Failed                    = "FAILED"
-)
-
-type CommitType int
-
-const (
-	GenesisCommitType CommitType = iota
-	SignedCommitType
-	AnchorCommitType
-)
+// StreamTypes are the stream types a commit's genesis CID may start, in the order to look them up.
+var StreamTypes = []StreamType{Tile, CAIP10Link}
 
 type CommitHeader struct {
 	Controllers []string         `json:"controllers,omitempty"`
@@ -49,7 +27,7 @@ type CommitHeader struct {
 }
 
 type GenesisHeader struct {
-	CommitHeader           `json:"commitHeader,omitempty"`
+	CommitHeader
 	Unique                 string `json:"unique,omitempty"`
 	ForbidControllerChange bool   `json:"forbidControllerChange,omitempty"`
 }
@@ -66,6 +44,11 @@ type RawCommit struct {
 	Prev   string           `json:"prev,omitempty"`
 }
 
+type CommitRecord struct {
+	CID   string           `json:"cid"`
+	Value *json.RawMessage `json:"value"`
+}
+
 type AnchorProof struct {
 	ChainID        string `json:"chainId,omitempty"`
 	BlockNumber    uint64 `json:"blockNumber,omitempty"`
@@ -113,10 +96,20 @@ type DAGJWS struct {
 	Link       string         `json:"link,omitempty"`
 }
 
+// SignedCommit is how the HTTP API carries a signed commit: the JWS envelope plus the base64 encoded dag-cbor block
+// holding the signed payload and, for commits signed with a session key, the block of the CACAO delegating to it.
+type SignedCommit struct {
+	JWS         DAGJWS `json:"jws"`
+	LinkedBlock string `json:"linkedBlock"`
+	CacaoBlock  string `json:"cacaoBlock,omitempty"`
+}
+
 type CommitData struct {
 	LogEntry         `json:"logEntry,omitempty"`
 	Commit           *json.RawMessage `json:"commit,omitempty"`
 	Envelope         DAGJWS           `json:"envelope,omitempty"`
+	LinkedBlock      []byte           `json:"linkedBlock,omitempty"`
+	CapabilityBlock  []byte           `json:"capabilityBlock,omitempty"`
 	Proof            AnchorProof      `json:"proof,omitempty"`
 	DisableTimeCheck bool             `json:"disableTimeCheck,omitempty"`
 }
@@ -127,7 +120,7 @@ type StreamState struct {
 	Next               StreamNext       `json:"next,omitempty"`
 	Metadata           StreamMetadata   `json:"metadata,omitempty"`
 	Signature          SignatureStatus  `json:"signature,omitempty"`
-	AnchorStatus       string           `json:"anchorStatus,omitempty"`
+	AnchorStatus       AnchorStatus     `json:"anchorStatus,omitempty"`
 	AnchorScheduledFor uint64           `json:"anchorScheduledFor,omitempty"`
 	AnchorProof        AnchorProof      `json:"anchorProof"`
 	Log                []LogEntry       `json:"log,omitempty"`
@@ -139,6 +132,23 @@ type StreamStateHolder struct {
 	State StreamState `json:"state,omitempty"`
 }
 
+// SyncResult reports how a Sync changed a stream's tip.
+type SyncResult struct {
+	Changed     bool
+	PreviousTip string
+	NewTip      string
+}
+
+// ReadOnlyError is returned when an operation that would change a stream is attempted on a read-only stream.
+type ReadOnlyError struct {
+	StreamID string
+	Op       string
+}
+
+func (e ReadOnlyError) Error() string {
+	return fmt.Sprintf("cannot %s stream<%s>: stream is read-only", e.Op, e.StreamID)
+}
+
 type Stream interface {
 	ID() string
 	// API() api.CeramicAPI
@@ -146,12 +156,12 @@ type Stream interface {
 	Content() interface{}
 	Controllers() []string
 	Tip() string
-	CommitID() threeid.CommitID
-	AllCommitIDs() []threeid.CommitID
-	AnchorCommitIDs() []threeid.CommitID
+	CommitID() CommitID
+	AllCommitIDs() []CommitID
+	AnchorCommitIDs() []CommitID
 	State() StreamState
-	Sync()
-	RequestAnchor() AnchorStatus
+	Sync(opts models.SyncOpts) (*SyncResult, error)
+	RequestAnchor() (AnchorStatus, error)
 	MakeReadOnly()
 	IsReadOnly() bool
 }
@@ -0,0 +1,125 @@
+package streams
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-varint"
+	"strings"
+)
+
+// https://github.com/ceramicnetwork/CIP/blob/main/CIPs/CIP-59/CIP-59.md
+
+const streamIDCodec = 0xce
+
+// StreamID identifies a stream by its type and genesis commit.
+type StreamID struct {
+	Type    StreamType
+	Genesis cid.Cid
+}
+
+// CommitID identifies a stream at a specific commit. A cid.Undef commit refers to the genesis commit.
+type CommitID struct {
+	StreamID
+	Commit cid.Cid
+}
+
+func NewStreamID(streamType StreamType, genesis cid.Cid) StreamID {
+	return StreamID{Type: streamType, Genesis: genesis}
+}
+
+// ParseStreamID parses the base36 form of a stream ID. Commit IDs are accepted and reduced to their stream ID.
+func ParseStreamID(id string) (*StreamID, error) {
+	commitID, err := ParseCommitID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &commitID.StreamID, nil
+}
+
+// ParseCommitID parses the base36 form of a commit ID. Stream IDs are accepted and refer to the genesis commit.
+func ParseCommitID(id string) (*CommitID, error) {
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "ceramic://"), "/ceramic/")
+	_, b, err := multibase.Decode(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream id<%s>: %w", id, err)
+	}
+	codec, n, err := varint.FromUvarint(b)
+	if err != nil || codec != streamIDCodec {
+		return nil, fmt.Errorf("invalid stream id<%s>: missing stream id codec", id)
+	}
+	b = b[n:]
+	streamType, n, err := varint.FromUvarint(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream id<%s>: %w", id, err)
+	}
+	b = b[n:]
+	genesisLen, genesis, err := cid.CidFromBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream id<%s>: %w", id, err)
+	}
+	b = b[genesisLen:]
+
+	commitID := CommitID{StreamID: NewStreamID(StreamType(streamType), genesis)}
+	switch {
+	case len(b) == 0, bytes.Equal(b, []byte{0}):
+		commitID.Commit = cid.Undef
+	default:
+		commitLen, commit, err := cid.CidFromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit id<%s>: %w", id, err)
+		}
+		if commitLen != len(b) {
+			return nil, fmt.Errorf("invalid commit id<%s>: trailing bytes", id)
+		}
+		commitID.Commit = commit
+	}
+	return &commitID, nil
+}
+
+func (s StreamID) Bytes() []byte {
+	b := varint.ToUvarint(streamIDCodec)
+	b = append(b, varint.ToUvarint(uint64(s.Type))...)
+	return append(b, s.Genesis.Bytes()...)
+}
+
+func (s StreamID) String() string {
+	return encodeBase36(s.Bytes())
+}
+
+// AtCommit returns the commit ID of this stream at the given commit. The genesis CID yields the genesis commit ID.
+func (s StreamID) AtCommit(commit cid.Cid) CommitID {
+	if commit.Equals(s.Genesis) {
+		commit = cid.Undef
+	}
+	return CommitID{StreamID: s, Commit: commit}
+}
+
+// CommitCID returns the CID of the commit, which is the genesis CID for the genesis commit.
+func (c CommitID) CommitCID() cid.Cid {
+	if c.Commit.Equals(cid.Undef) {
+		return c.Genesis
+	}
+	return c.Commit
+}
+
+func (c CommitID) Bytes() []byte {
+	b := c.StreamID.Bytes()
+	if c.Commit.Equals(cid.Undef) {
+		return append(b, 0)
+	}
+	return append(b, c.Commit.Bytes()...)
+}
+
+func (c CommitID) String() string {
+	return encodeBase36(c.Bytes())
+}
+
+func encodeBase36(b []byte) string {
+	encoded, err := multibase.Encode(multibase.Base36, b)
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
@@ -0,0 +1,49 @@
+package streams
+
+import (
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStreamID(t *testing.T) {
+	id := "k2t6wyfsu4pg2qvoorchoj23e8hf3eiis4w7bucllxkmlk91sjgluuag5syphl"
+
+	streamID, err := ParseStreamID(id)
+	assert.NoError(t, err)
+	assert.Equal(t, Tile, streamID.Type)
+	assert.Equal(t, testGenesisCID, streamID.Genesis.String())
+	assert.Equal(t, id, streamID.String())
+
+	t.Run("commit ids", func(tt *testing.T) {
+		genesisID := streamID.AtCommit(streamID.Genesis)
+		assert.Equal(tt, cid.Undef, genesisID.Commit)
+		assert.Equal(tt, streamID.Genesis, genesisID.CommitCID())
+
+		parsed, err := ParseCommitID(genesisID.String())
+		assert.NoError(tt, err)
+		assert.Equal(tt, genesisID, *parsed)
+
+		commit, err := cid.Decode(testSignedCID)
+		assert.NoError(tt, err)
+		commitID := streamID.AtCommit(commit)
+		assert.Equal(tt, commit, commitID.CommitCID())
+
+		parsed, err = ParseCommitID(commitID.String())
+		assert.NoError(tt, err)
+		assert.Equal(tt, commitID, *parsed)
+
+		reduced, err := ParseStreamID(commitID.String())
+		assert.NoError(tt, err)
+		assert.Equal(tt, *streamID, *reduced)
+	})
+
+	t.Run("invalid ids", func(tt *testing.T) {
+		_, err := ParseStreamID("bad")
+		assert.Error(tt, err)
+
+		_, err = ParseStreamID(testSignedCID)
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "missing stream id codec")
+	})
+}
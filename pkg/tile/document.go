@@ -0,0 +1,354 @@
+package tile
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/ipfs/go-cid"
+	"strings"
+	"sync"
+)
+
+// Document is a tile stream backed by a Ceramic node. It caches the stream's state, which Sync and Update refresh.
+// A read-only document is a snapshot: its state never changes, so it can be shared between goroutines as is.
+type Document struct {
+	ceramic api.CeramicAPI
+	id      streams.StreamID
+
+	mu       sync.RWMutex
+	state    streams.StreamState
+	readOnly bool
+}
+
+// CommitSigner signs commits on behalf of a controller of the stream. The signers in package jws implement it.
+type CommitSigner interface {
+	SignCommit(payload interface{}) (*streams.SignedCommit, error)
+}
+
+// Create creates a new tile with the given content and metadata. Unless metadata.Deterministic is set, the genesis
+// commit carries a random value so that tiles with the same content and metadata are distinct streams.
+func Create(ceramic api.CeramicAPI, content interface{}, metadata streams.TileMetadataArgs, opts models.CreateOpts) (*Document, error) {
+	if len(metadata.Controllers) == 0 {
+		return nil, errors.New("a tile needs at least one controller")
+	}
+	header := streams.GenesisHeader{
+		CommitHeader: streams.CommitHeader{
+			Controllers: metadata.Controllers,
+			Family:      metadata.Family,
+			Schema:      metadata.Schema,
+			Tags:        metadata.Tags,
+		},
+		ForbidControllerChange: metadata.ForbidControllerChange,
+	}
+	if !metadata.Deterministic {
+		unique := make([]byte, 12)
+		if _, err := rand.Read(unique); err != nil {
+			return nil, err
+		}
+		header.Unique = base64.StdEncoding.EncodeToString(unique)
+	}
+	genesis := map[string]interface{}{"header": header}
+	if content != nil {
+		genesis["data"] = content
+	}
+
+	resp, err := ceramic.CreateStream(api.CreateStreamRequest{
+		Type:    int(streams.Tile),
+		Genesis: genesis,
+		Opts:    opts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newDocument(ceramic, resp.Response.ID, resp.Response.State)
+}
+
+// Load loads the tile with the given stream ID. Loading a commit ID, or a stream ID with opts.AtTime set, yields a
+// read-only snapshot of the tile at that point in its history.
+func Load(ceramic api.CeramicAPI, streamID string, opts models.LoadOpts) (*Document, error) {
+	commitID, err := streams.ParseCommitID(streamID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: streamID, Opts: &opts})
+	if err != nil {
+		return nil, err
+	}
+	doc, err := newDocument(ceramic, streamID, resp.Response)
+	if err != nil {
+		return nil, err
+	}
+	isCommitID := strings.TrimPrefix(strings.TrimPrefix(streamID, "ceramic://"), "/ceramic/") != commitID.StreamID.String()
+	doc.readOnly = isCommitID || opts.AtTime > 0
+	return doc, nil
+}
+
+func newDocument(ceramic api.CeramicAPI, streamID string, state streams.StreamState) (*Document, error) {
+	id, err := streams.ParseStreamID(streamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Log) == 0 {
+		return nil, fmt.Errorf("stream<%s> has no log", streamID)
+	}
+	return &Document{
+		ceramic: ceramic,
+		id:      *id,
+		state:   state,
+	}, nil
+}
+
+func (d *Document) ID() string {
+	return d.id.String()
+}
+
+// Metadata returns the tile's metadata, including changes that are not anchored yet.
+func (d *Document) Metadata() streams.StreamMetadata {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if streams.HasPendingChanges(d.state) {
+		return d.state.Next.Metadata
+	}
+	return d.state.Metadata
+}
+
+// Content returns the content of the tile as a *json.RawMessage, including changes that are not anchored yet.
+func (d *Document) Content() interface{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if streams.HasPendingChanges(d.state) {
+		return d.state.Next.Content
+	}
+	return d.state.Content
+}
+
+func (d *Document) Controllers() []string {
+	return d.Metadata().Controllers
+}
+
+// Tip returns the CID of the latest commit in the tile's log.
+func (d *Document) Tip() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return tip(d.state)
+}
+
+func (d *Document) CommitID() streams.CommitID {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ids := d.commitIDs(func(streams.LogEntry) bool { return true })
+	if len(ids) == 0 {
+		return d.id.AtCommit(cid.Undef)
+	}
+	return ids[len(ids)-1]
+}
+
+func (d *Document) AllCommitIDs() []streams.CommitID {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.commitIDs(func(streams.LogEntry) bool { return true })
+}
+
+func (d *Document) AnchorCommitIDs() []streams.CommitID {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.commitIDs(func(entry streams.LogEntry) bool { return entry.Type == streams.AnchorCommitType })
+}
+
+func (d *Document) State() streams.StreamState {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.state
+}
+
+// MakeReadOnly turns the document into a snapshot of its current state. It cannot be made writable again; load the
+// tile anew for that.
+func (d *Document) MakeReadOnly() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.readOnly = true
+}
+
+func (d *Document) IsReadOnly() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.readOnly
+}
+
+// Update replaces the tile's content with content by applying a patch commit signed by signer.
+func (d *Document) Update(content interface{}, signer CommitSigner, opts models.UpdateOpts) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.readOnly {
+		return streams.ReadOnlyError{StreamID: d.id.String(), Op: "update"}
+	}
+
+	commit, err := streams.NewPatchCommit(d.state, content, streams.PatchOpts{})
+	if err != nil {
+		return err
+	}
+	signed, err := signer.SignCommit(commit)
+	if err != nil {
+		return fmt.Errorf("could not sign commit for stream<%s>: %w", d.id, err)
+	}
+	resp, err := d.ceramic.ApplyCommit(api.ApplyCommitRequest{
+		StreamID: d.id.String(),
+		Commit:   signed,
+		Opts:     opts,
+	})
+	if err != nil {
+		return fmt.Errorf("could not update stream<%s>: %w", d.id, err)
+	}
+	d.state = resp.Response
+	return nil
+}
+
+// ChangeController transfers the tile to newController with a header-only commit signed by signer, which must sign
+// for a current controller. It fails without contacting the node when the genesis forbids controller changes, and
+// returns the new state once the node reports newController as the tile's controller.
+func (d *Document) ChangeController(newController string, signer CommitSigner, opts models.UpdateOpts) (*streams.StreamState, error) {
+	if !strings.HasPrefix(newController, "did:") {
+		return nil, fmt.Errorf("invalid controller<%s>: not a DID", newController)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.readOnly {
+		return nil, streams.ReadOnlyError{StreamID: d.id.String(), Op: "change controller of"}
+	}
+	if d.state.Metadata.ForbidControllerChange {
+		return nil, fmt.Errorf("could not change controller of stream<%s>: %w", d.id, streams.ErrControllerChangeForbidden)
+	}
+
+	content := d.state.Content
+	if streams.HasPendingChanges(d.state) {
+		content = d.state.Next.Content
+	}
+	commit, err := streams.NewPatchCommit(d.state, content, streams.PatchOpts{})
+	if err != nil {
+		return nil, err
+	}
+	commit.Header.Controllers = []string{newController}
+	signed, err := signer.SignCommit(commit)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign commit for stream<%s>: %w", d.id, err)
+	}
+	resp, err := d.ceramic.ApplyCommit(api.ApplyCommitRequest{
+		StreamID: d.id.String(),
+		Commit:   signed,
+		Opts:     opts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not change controller of stream<%s>: %w", d.id, err)
+	}
+
+	state := resp.Response
+	metadata := state.Metadata
+	if streams.HasPendingChanges(state) {
+		metadata = state.Next.Metadata
+	}
+	if len(metadata.Controllers) != 1 || metadata.Controllers[0] != newController {
+		return nil, fmt.Errorf("node did not confirm controller<%s> for stream<%s>: got %v", newController, d.id, metadata.Controllers)
+	}
+	d.state = state
+	return &state, nil
+}
+
+// RequestAnchor asks the node to anchor the tile's latest commit and returns the resulting anchor status. Use
+// anchor.WaitForAnchor to wait for the anchor to complete.
+func (d *Document) RequestAnchor() (streams.AnchorStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.readOnly {
+		return streams.NotRequested, streams.ReadOnlyError{StreamID: d.id.String(), Op: "anchor"}
+	}
+
+	resp, err := d.ceramic.RequestAnchor(api.RequestAnchorRequest{StreamID: d.id.String()})
+	if err != nil {
+		return streams.NotRequested, fmt.Errorf("could not request anchor for stream<%s>: %w", d.id, err)
+	}
+	if resp.ResponseCode < 200 || resp.ResponseCode > 299 {
+		return streams.NotRequested, fmt.Errorf("could not request anchor for stream<%s>: status %d", d.id, resp.ResponseCode)
+	}
+	d.state.AnchorStatus = resp.AnchorStatus
+	return resp.AnchorStatus, nil
+}
+
+// Sync refreshes the tile's state from the node according to opts.Sync: NeverSync loads the node's state without the
+// node querying the network, PreferCache lets the node query the network if it does not have the stream, and
+// SyncAlways has the node query the network first, waiting at most opts.SyncTimeoutSeconds. A state from the node only
+// replaces the cached one when its log is canonical. Read-only documents never change, so syncing them is a no-op.
+func (d *Document) Sync(opts models.SyncOpts) (*streams.SyncResult, error) {
+	if d.IsReadOnly() {
+		current := d.Tip()
+		return &streams.SyncResult{PreviousTip: current, NewTip: current}, nil
+	}
+
+	resp, err := d.ceramic.GetStreamState(api.StreamStateRequest{
+		StreamID: d.id.String(),
+		Opts:     &models.LoadOpts{SyncOpts: &opts},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not sync stream<%s>: %w", d.id, err)
+	}
+	remote := resp.Response
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous := tip(d.state)
+	if len(remote.Log) > 0 && !d.readOnly {
+		resolution, err := streams.ResolveConflict(history(d.state), history(remote))
+		if err != nil {
+			return nil, fmt.Errorf("could not sync stream<%s>: %w", d.id, err)
+		}
+		if !resolution.WinnerIsFirst || resolution.Reason == streams.IdenticalLogs {
+			d.state = remote
+		}
+	}
+	current := tip(d.state)
+	return &streams.SyncResult{
+		Changed:     previous != current,
+		PreviousTip: previous,
+		NewTip:      current,
+	}, nil
+}
+
+func (d *Document) commitIDs(include func(streams.LogEntry) bool) []streams.CommitID {
+	var ids []streams.CommitID
+	for _, entry := range d.state.Log {
+		if !include(entry) {
+			continue
+		}
+		commit, err := cid.Decode(entry.CID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, d.id.AtCommit(commit))
+	}
+	return ids
+}
+
+var _ streams.Stream = (*Document)(nil)
+
+func tip(state streams.StreamState) string {
+	if len(state.Log) == 0 {
+		return ""
+	}
+	return state.Log[len(state.Log)-1].CID
+}
+
+// history describes a state's log for conflict resolution. Only the proof of the latest anchor is known.
+func history(state streams.StreamState) streams.LogHistory {
+	h := streams.LogHistory{Log: state.Log, Proofs: make(map[string]streams.AnchorProof)}
+	for i := len(state.Log) - 1; i >= 0; i-- {
+		if state.Log[i].Type == streams.AnchorCommitType {
+			h.Proofs[state.Log[i].CID] = state.AnchorProof
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,286 @@
+package tile
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/decentralgabe/ceramic-client-golang/internal/dagcbor"
+	"github.com/decentralgabe/ceramic-client-golang/internal/testutil"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/api"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/models"
+	"github.com/decentralgabe/ceramic-client-golang/pkg/streams"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const testController = "did:key:z6MkfZ6S4NVVTEuts8o5xFzRMR8eC6Y1bngoBQNnXiCvhH8H"
+
+func createTestDocument(t *testing.T, ceramic api.CeramicAPI) *Document {
+	doc, err := Create(ceramic, map[string]string{"title": "first"}, streams.TileMetadataArgs{
+		Controllers: []string{testController},
+		Family:      "test",
+	}, streams.DefaultCreateOpts)
+	assert.NoError(t, err)
+	return doc
+}
+
+// updateRemotely writes a new title through the node, as another client holding the stream would
+func updateRemotely(t *testing.T, ceramic api.CeramicAPI, doc *Document, title string) string {
+	resp, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: doc.ID()})
+	assert.NoError(t, err)
+	commit, err := streams.NewPatchCommit(resp.Response, map[string]string{"title": title}, streams.PatchOpts{})
+	assert.NoError(t, err)
+	applied, err := ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: doc.ID(), Commit: commit})
+	assert.NoError(t, err)
+	return applied.Response.Log[len(applied.Response.Log)-1].CID
+}
+
+// failingCeramic answers anchor requests with anchorCode when it is set, as the node does on errors.
+type failingCeramic struct {
+	*testutil.MemoryCeramic
+	anchorCode int
+}
+
+func (f *failingCeramic) RequestAnchor(req api.RequestAnchorRequest) (*api.RequestAnchorResponse, error) {
+	if f.anchorCode != 0 {
+		return &api.RequestAnchorResponse{ResponseCode: f.anchorCode}, nil
+	}
+	return f.MemoryCeramic.RequestAnchor(req)
+}
+
+// recordingCeramic remembers the load options of the last stream state request.
+type recordingCeramic struct {
+	*testutil.MemoryCeramic
+	opts *models.LoadOpts
+}
+
+func (r *recordingCeramic) GetStreamState(req api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	r.opts = req.Opts
+	return r.MemoryCeramic.GetStreamState(req)
+}
+
+func TestCreateAndLoad(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	doc := createTestDocument(t, ceramic)
+
+	assert.NotEmpty(t, doc.ID())
+	assert.Equal(t, []string{testController}, doc.Controllers())
+	assert.Equal(t, "test", doc.Metadata().Family)
+	assert.JSONEq(t, `{"title":"first"}`, string(*doc.Content().(*json.RawMessage)))
+	assert.Equal(t, doc.State().Log[0].CID, doc.Tip())
+	assert.Equal(t, doc.Tip(), doc.CommitID().CommitCID().String())
+	assert.Len(t, doc.AllCommitIDs(), 1)
+	assert.Empty(t, doc.AnchorCommitIDs())
+
+	loaded, err := Load(ceramic, doc.ID(), streams.DefaultLoadOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, doc.State(), loaded.State())
+
+	other := createTestDocument(t, ceramic)
+	assert.NotEqual(t, doc.ID(), other.ID())
+
+	_, err = Create(ceramic, nil, streams.TileMetadataArgs{}, streams.DefaultCreateOpts)
+	assert.Error(t, err)
+}
+
+func TestSync(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	doc := createTestDocument(t, ceramic)
+	genesis := doc.Tip()
+	newTip := updateRemotely(t, ceramic, doc, "second")
+
+	t.Run("never sync loads the node's state", func(tt *testing.T) {
+		recording := &recordingCeramic{MemoryCeramic: ceramic}
+		local := createTestDocument(tt, recording)
+		localGenesis := local.Tip()
+		localTip := updateRemotely(tt, ceramic, local, "second")
+		result, err := local.Sync(models.SyncOpts{Sync: models.NeverSync})
+		assert.NoError(tt, err)
+		assert.Equal(tt, streams.SyncResult{Changed: true, PreviousTip: localGenesis, NewTip: localTip}, *result)
+		assert.Equal(tt, models.NeverSync, recording.opts.SyncOpts.Sync)
+	})
+
+	t.Run("prefer cache picks up the node's state", func(tt *testing.T) {
+		result, err := doc.Sync(models.SyncOpts{Sync: models.PreferCache})
+		assert.NoError(tt, err)
+		assert.Equal(tt, streams.SyncResult{Changed: true, PreviousTip: genesis, NewTip: newTip}, *result)
+		assert.Equal(tt, newTip, doc.Tip())
+		assert.Len(tt, doc.AllCommitIDs(), 2)
+	})
+
+	t.Run("sync always without changes", func(tt *testing.T) {
+		result, err := doc.Sync(models.SyncOpts{Sync: models.SyncAlways, SyncTimeoutSeconds: 3})
+		assert.NoError(tt, err)
+		assert.False(tt, result.Changed)
+		assert.Equal(tt, newTip, result.NewTip)
+	})
+
+	t.Run("stale node state is ignored", func(tt *testing.T) {
+		genesisID := doc.AllCommitIDs()[0].String()
+		resp, err := ceramic.GetStreamState(api.StreamStateRequest{StreamID: genesisID})
+		assert.NoError(tt, err)
+		assert.Len(tt, resp.Response.Log, 1)
+
+		staleDoc := &Document{ceramic: staleCeramic{ceramic, resp.Response}, id: doc.id, state: doc.State()}
+		result, err := staleDoc.Sync(models.SyncOpts{Sync: models.PreferCache})
+		assert.NoError(tt, err)
+		assert.False(tt, result.Changed)
+		assert.Equal(tt, newTip, staleDoc.Tip())
+	})
+}
+
+// staleCeramic always returns the same, outdated, state
+type staleCeramic struct {
+	api.CeramicAPI
+	state streams.StreamState
+}
+
+func (s staleCeramic) GetStreamState(api.StreamStateRequest) (*api.StreamStateResponse, error) {
+	return &api.StreamStateResponse{Response: s.state}, nil
+}
+
+// testSigner wraps commits in a signed container without a real signature, which the in-memory node accepts
+type testSigner struct{}
+
+func (testSigner) SignCommit(payload interface{}) (*streams.SignedCommit, error) {
+	block, err := dagcbor.Encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	link, err := dagcbor.CID(block)
+	if err != nil {
+		return nil, err
+	}
+	return &streams.SignedCommit{
+		JWS: streams.DAGJWS{
+			Payload:    base64.RawURLEncoding.EncodeToString(link.Bytes()),
+			Signatures: []streams.JWSSignature{{Protected: "e30", Signature: "c2ln"}},
+			Link:       link.String(),
+		},
+		LinkedBlock: base64.StdEncoding.EncodeToString(block),
+	}, nil
+}
+
+func TestUpdate(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	doc := createTestDocument(t, ceramic)
+
+	err := doc.Update(map[string]string{"title": "second"}, testSigner{}, streams.DefaultUpdateOpts)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"second"}`, string(*doc.Content().(*json.RawMessage)))
+	assert.Len(t, doc.AllCommitIDs(), 2)
+
+	loaded, err := Load(ceramic, doc.ID(), streams.DefaultLoadOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, doc.Tip(), loaded.Tip())
+	assert.False(t, loaded.IsReadOnly())
+}
+
+func TestReadOnly(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	doc := createTestDocument(t, ceramic)
+	genesisID := doc.CommitID().String()
+	updateRemotely(t, ceramic, doc, "second")
+
+	t.Run("loading a commit gives a snapshot", func(tt *testing.T) {
+		snapshot, err := Load(ceramic, genesisID, streams.DefaultLoadOpts)
+		assert.NoError(tt, err)
+		assert.True(tt, snapshot.IsReadOnly())
+		assert.Equal(tt, doc.ID(), snapshot.ID())
+		assert.JSONEq(tt, `{"title":"first"}`, string(*snapshot.Content().(*json.RawMessage)))
+
+		err = snapshot.Update(map[string]string{"title": "third"}, testSigner{}, streams.DefaultUpdateOpts)
+		var readOnlyErr streams.ReadOnlyError
+		assert.True(tt, errors.As(err, &readOnlyErr))
+		assert.Equal(tt, streams.ReadOnlyError{StreamID: doc.ID(), Op: "update"}, readOnlyErr)
+
+		result, err := snapshot.Sync(models.SyncOpts{Sync: models.SyncAlways})
+		assert.NoError(tt, err)
+		assert.False(tt, result.Changed)
+		assert.Len(tt, snapshot.AllCommitIDs(), 1)
+	})
+
+	t.Run("loading at a time gives a snapshot", func(tt *testing.T) {
+		snapshot, err := Load(ceramic, doc.ID(), models.LoadOpts{AtTime: 1})
+		assert.NoError(tt, err)
+		assert.True(tt, snapshot.IsReadOnly())
+	})
+
+	t.Run("make read only", func(tt *testing.T) {
+		loaded, err := Load(ceramic, doc.ID(), streams.DefaultLoadOpts)
+		assert.NoError(tt, err)
+		assert.False(tt, loaded.IsReadOnly())
+		tip := loaded.Tip()
+
+		loaded.MakeReadOnly()
+		assert.True(tt, loaded.IsReadOnly())
+		assert.Error(tt, loaded.Update(map[string]string{"title": "third"}, testSigner{}, streams.DefaultUpdateOpts))
+		assert.Equal(tt, tip, loaded.Tip())
+	})
+}
+
+func TestRequestAnchor(t *testing.T) {
+	ceramic := testutil.NewMemoryCeramic()
+	doc := createTestDocument(t, ceramic)
+
+	status, err := doc.RequestAnchor()
+	assert.NoError(t, err)
+	assert.Equal(t, streams.Pending, status)
+	assert.Equal(t, streams.Pending, doc.State().AnchorStatus)
+
+	snapshot, err := Load(ceramic, doc.CommitID().String(), streams.DefaultLoadOpts)
+	assert.NoError(t, err)
+	_, err = snapshot.RequestAnchor()
+	assert.Equal(t, streams.ReadOnlyError{StreamID: doc.ID(), Op: "anchor"}, err)
+
+	t.Run("error status", func(tt *testing.T) {
+		failing := &failingCeramic{MemoryCeramic: testutil.NewMemoryCeramic()}
+		doc := createTestDocument(tt, failing)
+		_, err := doc.RequestAnchor()
+		assert.NoError(tt, err)
+
+		failing.anchorCode = http.StatusInternalServerError
+		_, err = doc.RequestAnchor()
+		assert.Error(tt, err)
+		assert.Contains(tt, err.Error(), "status 500")
+		assert.Equal(tt, streams.Pending, doc.State().AnchorStatus)
+	})
+}
+
+func TestChangeController(t *testing.T) {
+	const newController = "did:key:z6MktvqCyLxTsXUH1tUZncNdVeEZ7hNh7npPRbUU27GTrYb8"
+	ceramic := testutil.NewMemoryCeramic()
+
+	t.Run("transfers the tile", func(tt *testing.T) {
+		doc := createTestDocument(tt, ceramic)
+		state, err := doc.ChangeController(newController, testSigner{}, streams.DefaultUpdateOpts)
+		assert.NoError(tt, err)
+		assert.Equal(tt, []string{newController}, state.Next.Metadata.Controllers)
+		assert.Equal(tt, []string{newController}, doc.Controllers())
+		assert.JSONEq(tt, `{"title":"first"}`, string(*doc.Content().(*json.RawMessage)))
+
+		_, err = doc.ChangeController("not a did", testSigner{}, streams.DefaultUpdateOpts)
+		assert.Error(tt, err)
+	})
+
+	t.Run("refused when the genesis forbids it", func(tt *testing.T) {
+		doc, err := Create(ceramic, nil, streams.TileMetadataArgs{
+			Controllers:            []string{testController},
+			ForbidControllerChange: true,
+		}, streams.DefaultCreateOpts)
+		assert.NoError(tt, err)
+		tip := doc.Tip()
+
+		_, err = doc.ChangeController(newController, testSigner{}, streams.DefaultUpdateOpts)
+		assert.True(tt, errors.Is(err, streams.ErrControllerChangeForbidden))
+		assert.Equal(tt, tip, doc.Tip())
+
+		// the node refuses the commit as well
+		commit, err := streams.NewPatchCommit(doc.State(), doc.Content(), streams.PatchOpts{})
+		assert.NoError(tt, err)
+		commit.Header.Controllers = []string{newController}
+		_, err = ceramic.ApplyCommit(api.ApplyCommitRequest{StreamID: doc.ID(), Commit: commit})
+		assert.True(tt, errors.Is(err, streams.ErrControllerChangeForbidden))
+	})
+}